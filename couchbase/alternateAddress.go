@@ -0,0 +1,109 @@
+package couchbase
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// Provider network modes for selection of node addresses
+	networkAuto     = "auto"
+	networkDefault  = "default"
+	networkExternal = "external"
+
+	// alternateAddressPath is couchbase REST API path for external alternate address of node
+	alternateAddressPath = "/node/controller/setupAlternateAddresses/external"
+)
+
+// alternateAddressPorts contains names of service ports which can be remapped by alternate address
+var alternateAddressPorts = []string{
+	"mgmt", "mgmtSSL",
+	"kv", "kvSSL",
+	"capi", "capiSSL",
+	"n1ql", "n1qlSSL",
+	"fts", "ftsSSL",
+	"cbas", "cbasSSL",
+	"eventingAdminPort", "eventingSSL",
+	"backupAPI", "backupAPIHTTPS",
+}
+
+// alternateAddress custom structure for alternate address of couchbase node
+type alternateAddress struct {
+	Hostname string         `json:"hostname"`
+	Ports    map[string]int `json:"ports"`
+}
+
+// clusterNodeExt custom structure for node services returned by /pools/default/nodeServices
+type clusterNodeExt struct {
+	Hostname           string                      `json:"hostname"`
+	ThisNode           bool                        `json:"thisNode"`
+	Services           map[string]int              `json:"services"`
+	AlternateAddresses map[string]alternateAddress `json:"alternateAddresses"`
+}
+
+// clusterNodeServices custom structure for /pools/default/nodeServices response
+type clusterNodeServices struct {
+	NodesExt []clusterNodeExt `json:"nodesExt"`
+}
+
+// endpoint function returns address of node service. When external network is used alternate hostname
+// and remapped port are returned if they are configured
+func (n *clusterNodeExt) endpoint(key string, external bool) (managementEndpoint, bool) {
+	port, ok := n.Services[key]
+	if !ok {
+		return managementEndpoint{}, false
+	}
+
+	endpoint := managementEndpoint{host: n.Hostname, port: port}
+
+	if alternate, ok := n.AlternateAddresses[networkExternal]; external && ok {
+		endpoint.host = alternate.Hostname
+		if alternatePort, ok := alternate.Ports[key]; ok {
+			endpoint.port = alternatePort
+		}
+	}
+
+	return endpoint, true
+}
+
+// getAlternateAddress function returns external alternate address of node, nil is returned when node
+// doesn't have external alternate address
+func (cc *Connection) getAlternateAddress(c context.Context, node string) (*alternateAddress, error) {
+	var nodeServices clusterNodeServices
+
+	if err := cc.management().requestNode(c, http.MethodGet, node, "/pools/default/nodeServices", nil, &nodeServices); err != nil {
+		return nil, err
+	}
+
+	for _, n := range nodeServices.NodesExt {
+		if !n.ThisNode {
+			continue
+		}
+
+		alternate, ok := n.AlternateAddresses[networkExternal]
+		if !ok {
+			return nil, nil
+		}
+		return &alternate, nil
+	}
+
+	return nil, nil
+}
+
+// setAlternateAddress function configures external alternate address of node
+func (cc *Connection) setAlternateAddress(c context.Context, node string, address alternateAddress) error {
+	form := url.Values{}
+	form.Set("hostname", address.Hostname)
+	for name, port := range address.Ports {
+		form.Set(name, strconv.Itoa(port))
+	}
+
+	return cc.management().requestNode(c, http.MethodPut, node, alternateAddressPath, form, nil)
+}
+
+// deleteAlternateAddress function removes external alternate address of node
+func (cc *Connection) deleteAlternateAddress(c context.Context, node string) error {
+	return cc.management().requestNode(c, http.MethodDelete, node, alternateAddressPath, nil, nil)
+}
@@ -0,0 +1,270 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// bucketAutoCompaction custom structure for auto compaction settings of bucket in /pools/default/buckets/<name> response.
+// Auto compaction settings are false when bucket uses cluster auto compaction settings
+type bucketAutoCompaction struct {
+	AutoCompactionSettings json.RawMessage `json:"autoCompactionSettings"`
+	PurgeInterval          float64         `json:"purgeInterval"`
+}
+
+// autoCompactionSettings custom structure for bucket auto compaction settings because couchbase golang sdk doesn't
+// support auto compaction
+type autoCompactionSettings struct {
+	ParallelDBAndViewCompaction    bool                      `json:"parallelDBAndViewCompaction"`
+	DatabaseFragmentationThreshold fragmentationThreshold    `json:"databaseFragmentationThreshold"`
+	ViewFragmentationThreshold     fragmentationThreshold    `json:"viewFragmentationThreshold"`
+	MagmaFragmentationPercentage   int                       `json:"magmaFragmentationPercentage"`
+	AllowedTimePeriod              *autoCompactionTimePeriod `json:"allowedTimePeriod"`
+}
+
+// fragmentationThreshold custom structure for fragmentation threshold of database and view files
+type fragmentationThreshold struct {
+	Percentage compactionThreshold `json:"percentage"`
+	Size       compactionThreshold `json:"size"`
+}
+
+// autoCompactionTimePeriod custom structure for time period when auto compaction is allowed
+type autoCompactionTimePeriod struct {
+	FromHour     int  `json:"fromHour"`
+	FromMinute   int  `json:"fromMinute"`
+	ToHour       int  `json:"toHour"`
+	ToMinute     int  `json:"toMinute"`
+	AbortOutside bool `json:"abortOutside"`
+}
+
+// compactionThreshold custom type for fragmentation threshold which is number or "undefined" string when it isn't set
+type compactionThreshold int
+
+// UnmarshalJSON function decodes fragmentation threshold, undefined threshold is decoded as 0
+func (t *compactionThreshold) UnmarshalJSON(data []byte) error {
+	var value json.Number
+	if err := json.Unmarshal(data, &value); err != nil {
+		*t = 0
+		return nil
+	}
+
+	threshold, err := value.Int64()
+	if err != nil {
+		return fmt.Errorf("cannot decode fragmentation threshold: %s", data)
+	}
+	*t = compactionThreshold(threshold)
+
+	return nil
+}
+
+// autoCompactionStructure function provide terraform bucket auto compaction structure
+func autoCompactionStructure() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			keyAutoCompactionDatabaseFragmentationPercentage: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionDatabaseFragmentationPercentage, 2, 100),
+				Description:      "Database fragmentation in percent which triggers compaction",
+			},
+			keyAutoCompactionDatabaseFragmentationSize: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionDatabaseFragmentationSize, 1, math.MaxInt),
+				Description:      "Database fragmentation in bytes which triggers compaction",
+			},
+			keyAutoCompactionViewFragmentationPercentage: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionViewFragmentationPercentage, 2, 100),
+				Description:      "View fragmentation in percent which triggers compaction",
+			},
+			keyAutoCompactionViewFragmentationSize: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionViewFragmentationSize, 1, math.MaxInt),
+				Description:      "View fragmentation in bytes which triggers compaction",
+			},
+			keyAutoCompactionMagmaFragmentationPercentage: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Computed:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionMagmaFragmentationPercentage, 10, 100),
+				Description:      "Magma storage fragmentation in percent which triggers compaction",
+			},
+			keyAutoCompactionParallelCompaction: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Compact database and view files in parallel",
+			},
+			keyAutoCompactionPurgeInterval: {
+				Type:        schema.TypeFloat,
+				Optional:    true,
+				Computed:    true,
+				Description: "Metadata purge interval in days",
+			},
+			keyAutoCompactionAllowedTimePeriod: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem:        autoCompactionTimePeriodStructure(),
+				Description: "Time period when compaction is allowed",
+			},
+		},
+	}
+}
+
+// autoCompactionTimePeriodStructure function provide terraform auto compaction time period structure
+func autoCompactionTimePeriodStructure() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			keyAutoCompactionFromHour: {
+				Type:             schema.TypeInt,
+				Required:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionFromHour, 0, 23),
+				Description:      "Start hour of compaction",
+			},
+			keyAutoCompactionFromMinute: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          0,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionFromMinute, 0, 59),
+				Description:      "Start minute of compaction",
+			},
+			keyAutoCompactionToHour: {
+				Type:             schema.TypeInt,
+				Required:         true,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionToHour, 0, 23),
+				Description:      "End hour of compaction",
+			},
+			keyAutoCompactionToMinute: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          0,
+				ValidateDiagFunc: validateIntRange(keyAutoCompactionToMinute, 0, 59),
+				Description:      "End minute of compaction",
+			},
+			keyAutoCompactionAbortOutside: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Abort compaction which runs outside of time period",
+			},
+		},
+	}
+}
+
+// convertAutoCompactionToForm function converts terraform auto compaction block to bucket REST API parameters.
+// Missing block resets bucket to cluster auto compaction settings
+func convertAutoCompactionToForm(rawAutoCompaction []interface{}) url.Values {
+	form := url.Values{}
+
+	if len(rawAutoCompaction) == 0 {
+		form.Set("autoCompactionDefined", "false")
+		return form
+	}
+
+	autoCompaction, _ := rawAutoCompaction[0].(map[string]interface{})
+	integer := func(values map[string]interface{}, key string) int {
+		value, _ := values[key].(int)
+		return value
+	}
+	boolean := func(values map[string]interface{}, key string) bool {
+		value, _ := values[key].(bool)
+		return value
+	}
+	threshold := func(param, key string) {
+		if value := integer(autoCompaction, key); value > 0 {
+			form.Set(param, strconv.Itoa(value))
+		}
+	}
+
+	form.Set("autoCompactionDefined", "true")
+	form.Set("parallelDBAndViewCompaction", strconv.FormatBool(boolean(autoCompaction, keyAutoCompactionParallelCompaction)))
+	threshold("databaseFragmentationThreshold[percentage]", keyAutoCompactionDatabaseFragmentationPercentage)
+	threshold("databaseFragmentationThreshold[size]", keyAutoCompactionDatabaseFragmentationSize)
+	threshold("viewFragmentationThreshold[percentage]", keyAutoCompactionViewFragmentationPercentage)
+	threshold("viewFragmentationThreshold[size]", keyAutoCompactionViewFragmentationSize)
+	threshold("magmaFragmentationPercentage", keyAutoCompactionMagmaFragmentationPercentage)
+
+	if purgeInterval, _ := autoCompaction[keyAutoCompactionPurgeInterval].(float64); purgeInterval > 0 {
+		form.Set("purgeInterval", strconv.FormatFloat(purgeInterval, 'f', -1, 64))
+	}
+
+	if periods, _ := autoCompaction[keyAutoCompactionAllowedTimePeriod].([]interface{}); len(periods) > 0 {
+		period, _ := periods[0].(map[string]interface{})
+		form.Set("allowedTimePeriod[fromHour]", strconv.Itoa(integer(period, keyAutoCompactionFromHour)))
+		form.Set("allowedTimePeriod[fromMinute]", strconv.Itoa(integer(period, keyAutoCompactionFromMinute)))
+		form.Set("allowedTimePeriod[toHour]", strconv.Itoa(integer(period, keyAutoCompactionToHour)))
+		form.Set("allowedTimePeriod[toMinute]", strconv.Itoa(integer(period, keyAutoCompactionToMinute)))
+		form.Set("allowedTimePeriod[abortOutside]", strconv.FormatBool(boolean(period, keyAutoCompactionAbortOutside)))
+	}
+
+	return form
+}
+
+// convertAutoCompactionToList function converts bucket auto compaction settings to terraform list. Empty list is returned
+// when bucket uses cluster auto compaction settings
+func convertAutoCompactionToList(settings *autoCompactionSettings, purgeInterval float64) []interface{} {
+	if settings == nil {
+		return []interface{}{}
+	}
+
+	periods := []interface{}{}
+	if period := settings.AllowedTimePeriod; period != nil {
+		periods = append(periods, map[string]interface{}{
+			keyAutoCompactionFromHour:     period.FromHour,
+			keyAutoCompactionFromMinute:   period.FromMinute,
+			keyAutoCompactionToHour:       period.ToHour,
+			keyAutoCompactionToMinute:     period.ToMinute,
+			keyAutoCompactionAbortOutside: period.AbortOutside,
+		})
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			keyAutoCompactionDatabaseFragmentationPercentage: int(settings.DatabaseFragmentationThreshold.Percentage),
+			keyAutoCompactionDatabaseFragmentationSize:       int(settings.DatabaseFragmentationThreshold.Size),
+			keyAutoCompactionViewFragmentationPercentage:     int(settings.ViewFragmentationThreshold.Percentage),
+			keyAutoCompactionViewFragmentationSize:           int(settings.ViewFragmentationThreshold.Size),
+			keyAutoCompactionMagmaFragmentationPercentage:    settings.MagmaFragmentationPercentage,
+			keyAutoCompactionParallelCompaction:              settings.ParallelDBAndViewCompaction,
+			keyAutoCompactionPurgeInterval:                   purgeInterval,
+			keyAutoCompactionAllowedTimePeriod:               periods,
+		},
+	}
+}
+
+// getBucketAutoCompaction custom function for get bucket auto compaction settings because couchbase golang sdk doesn't
+// support auto compaction. Nil settings are returned when bucket uses cluster auto compaction settings
+func (cc *Configuration) getBucketAutoCompaction(c context.Context, bucketName string) (*autoCompactionSettings, float64, error) {
+	var bucket bucketAutoCompaction
+
+	if err := cc.Management.get(c, managementServiceCluster, fmt.Sprintf("/pools/default/buckets/%s", url.PathEscape(bucketName)), &bucket); err != nil {
+		return nil, 0, err
+	}
+
+	var defined bool
+	if err := json.Unmarshal(bucket.AutoCompactionSettings, &defined); err == nil || len(bucket.AutoCompactionSettings) == 0 {
+		return nil, 0, nil
+	}
+
+	var settings autoCompactionSettings
+	if err := json.Unmarshal(bucket.AutoCompactionSettings, &settings); err != nil {
+		return nil, 0, fmt.Errorf("cannot decode auto compaction settings of bucket: %s error: %s", bucketName, err)
+	}
+
+	return &settings, bucket.PurgeInterval, nil
+}
+
+// setBucketAutoCompaction custom function for set bucket auto compaction settings with bucket REST API
+func (cc *Configuration) setBucketAutoCompaction(c context.Context, bucketName string, rawAutoCompaction []interface{}) error {
+	return cc.Management.post(c, managementServiceCluster, fmt.Sprintf("/pools/default/buckets/%s", url.PathEscape(bucketName)), convertAutoCompactionToForm(rawAutoCompaction), nil)
+}
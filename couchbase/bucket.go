@@ -1,68 +1,84 @@
 package couchbase
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 
 	"github.com/couchbase/gocb/v2"
 )
 
 // getBucketConflictResolutionType custom function for get bucket conflict resolution type because couchbase golang sdk doesn't support to get conflict
-// resolution type in gocb v2 version. Currently gocb v2 doesn't support some operations so we must use http/https
-// and client-to-node ports for connection. You can read more about ports here:
-// https://docs.couchbase.com/server/current/install/install-ports.html
-func (cc *Connection) getBucketConflictResolutionType(bucketName string) (*gocb.ConflictResolutionType, error) {
-	var (
-		conflictResolutionType conflictResolutionType
-		scheme                 string
-	)
-
-	if cc.ClusterOptions.SecurityConfig.TLSRootCAs == nil {
-		scheme = "http"
-	} else {
-		scheme = "https"
+// resolution type in gocb v2 version.
+func (cc *Configuration) getBucketConflictResolutionType(c context.Context, bucketName string) (*gocb.ConflictResolutionType, error) {
+	var conflictResolutionType conflictResolutionType
+
+	if err := cc.Management.get(c, managementServiceCluster, fmt.Sprintf("/pools/default/buckets/%s", url.PathEscape(bucketName)), &conflictResolutionType); err != nil {
+		return nil, err
 	}
 
-	client := http.Client{
-		Timeout: cc.ClusterOptions.TimeoutsConfig.ManagementTimeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				// nolint:gosec
-				InsecureSkipVerify: cc.ClusterOptions.SecurityConfig.TLSSkipVerify,
-				RootCAs:            cc.ClusterOptions.SecurityConfig.TLSRootCAs,
-			},
-		},
+	return &conflictResolutionType.ConflictResolutionType, nil
+}
+
+// clusterMemory custom structure for memory quota of data service in /pools/default response
+type clusterMemory struct {
+	MemoryQuota   uint64 `json:"memoryQuota"`
+	StorageTotals struct {
+		RAM struct {
+			QuotaTotal        uint64 `json:"quotaTotal"`
+			QuotaUsed         uint64 `json:"quotaUsed"`
+			QuotaTotalPerNode uint64 `json:"quotaTotalPerNode"`
+			QuotaUsedPerNode  uint64 `json:"quotaUsedPerNode"`
+		} `json:"ram"`
+	} `json:"storageTotals"`
+}
+
+// perNode function returns total and used ram quota of one data node in MiB because bucket ram quota is set per node.
+// Couchbase older than 7.0 doesn't return per node totals, they are computed from cluster wide totals
+func (m *clusterMemory) perNode() (uint64, uint64) {
+	ram := m.StorageTotals.RAM
+	if ram.QuotaTotalPerNode > 0 {
+		return ram.QuotaTotalPerNode / mebibyte, ram.QuotaUsedPerNode / mebibyte
 	}
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s://%s:%d/pools/default/buckets/%s", scheme, cc.Address, cc.ClientPort, bucketName), nil)
-	if err != nil {
-		return nil, err
+	if m.MemoryQuota == 0 || ram.QuotaTotal == 0 {
+		return 0, 0
 	}
-	req.SetBasicAuth(cc.ClusterOptions.Username, cc.ClusterOptions.Password)
 
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	nodes := ram.QuotaTotal / (m.MemoryQuota * mebibyte)
+	if nodes == 0 {
+		nodes = 1
 	}
 
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			return
-		}
-	}()
+	return m.MemoryQuota, ram.QuotaUsed / nodes / mebibyte
+}
 
-	resData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+// getClusterRAMQuota function returns total and used ram quota of data node in MiB from cluster manager storage totals
+func (cc *Connection) getClusterRAMQuota(c context.Context) (uint64, uint64, error) {
+	var memory clusterMemory
+
+	if err := cc.management().get(c, managementServiceCluster, "/pools/default", &memory); err != nil {
+		return 0, 0, err
 	}
 
-	if err := json.Unmarshal(resData, &conflictResolutionType); err != nil {
-		return nil, err
+	total, used := memory.perNode()
+	return total, used, nil
+}
+
+// bucketBasicStats custom structure for basic stats of bucket in /pools/default/buckets/<name> response
+type bucketBasicStats struct {
+	BasicStats struct {
+		ItemCount uint64 `json:"itemCount"`
+	} `json:"basicStats"`
+}
+
+// getBucketItemCount custom function for get number of items in bucket from bucket basic stats
+func (cc *Configuration) getBucketItemCount(c context.Context, bucketName string) (uint64, error) {
+	var stats bucketBasicStats
+
+	if err := cc.Management.get(c, managementServiceCluster, fmt.Sprintf("/pools/default/buckets/%s", url.PathEscape(bucketName)), &stats); err != nil {
+		return 0, err
 	}
 
-	return &conflictResolutionType.ConflictResolutionType, nil
+	return stats.BasicStats.ItemCount, nil
 }
@@ -2,6 +2,7 @@ package couchbase
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/couchbase/gocb/v2"
 )
@@ -26,8 +27,8 @@ func (e *ErrCollectionNotFound) Error() string {
 
 // findCollection function will find collection based on name and scope name in couchbase.
 // custom error message is returnet when scope is not found
-func findCollection(cm *gocb.CollectionManagerV2, name string, scopeName string) (*gocb.CollectionSpec, error) {
-	scope, err := findScope(cm, scopeName)
+func findCollection(cm collectionManager, name string, scopeName string, timeout time.Duration) (*gocb.CollectionSpec, error) {
+	scope, err := findScope(cm, scopeName, timeout)
 
 	if err != nil {
 		return nil, err
@@ -44,8 +45,8 @@ func findCollection(cm *gocb.CollectionManagerV2, name string, scopeName string)
 
 // getCollectionHistorySettings function creates collection history settings struct based on existing bucket storage type.
 // It returns nil if bucket storage type is not magma.
-func (cc *Configuration) getCollectionHistorySettings(bucketName string, history bool) (*gocb.CollectionHistorySettings, error) {
-	bucket, err := cc.BucketManager.GetBucket(bucketName, nil)
+func (cc *Configuration) getCollectionHistorySettings(bucketName string, history bool, timeout time.Duration) (*gocb.CollectionHistorySettings, error) {
+	bucket, err := cc.BucketManager.GetBucket(bucketName, &gocb.GetBucketOptions{Timeout: timeout})
 
 	if err != nil {
 		return nil, err
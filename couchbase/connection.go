@@ -1,19 +1,58 @@
 package couchbase
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/couchbase/gocbcore/v10/connstr"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Connection struct contain information about connection parameters.
+// Connection owns shared couchbase configuration which is created during provider configuration and reused by all resources.
 type Connection struct {
-	Scheme         string
-	Address        string
-	NodePort       int
-	ClientPort     int
-	ClusterOptions gocb.ClusterOptions
+	Scheme           string
+	ConnectionString string
+	Network          string
+	Address          string
+	NodePort         int
+	ClientPort       int
+	ClusterOptions   gocb.ClusterOptions
+	Retry            RetryPolicy
+	Tracer           trace.Tracer
+
+	// DeletionProtection is default deletion protection of buckets, scopes and collections
+	DeletionProtection bool
+
+	// Version is couchbase server version detected during provider configuration
+	Version      clusterVersion
+	versionMutex sync.Mutex
+
+	// tracerProvider exports spans when tracing is enabled
+	tracerProvider *sdktrace.TracerProvider
+	// tracerOutput is file written by file trace exporter
+	tracerOutput io.Closer
+
+	mutex         sync.Mutex
+	configuration *Configuration
+
+	managementOnce   sync.Once
+	managementClient managementAPI
+
+	// queryIndexMutex serializes query index DDL because indexer doesn't allow concurrent index creation
+	queryIndexMutex sync.Mutex
+
+	// indexStatusList caches indexer status of whole cluster so refresh of many query indexes downloads it once
+	indexStatusMutex   sync.Mutex
+	indexStatusList    *queryIndexStatusList
+	indexStatusFetched time.Time
 }
 
 // conflictResolutionType custom struct for bucket conflict resolution type because couchbase golang sdk doesn't support to get conflict
@@ -23,34 +62,72 @@ type conflictResolutionType struct {
 }
 
 // Configuration struct contains information about cluster and bucket manager.
+// Managers are interfaces so resources can be tested without couchbase cluster
 type Configuration struct {
-	Cluster           *gocb.Cluster
-	BucketManager     *gocb.BucketManager
-	UserManager       *gocb.UserManager
-	QueryIndexManager *gocb.QueryIndexManager
+	Cluster           clusterManager
+	BucketManager     bucketManager
+	UserManager       userManager
+	QueryIndexManager queryIndexManager
+	Management        managementAPI
 }
 
-// CouchbaseInitialization function creates connection to couchbase.
-func (cc *Connection) CouchbaseInitialization() (*Configuration, diag.Diagnostics) {
+// CouchbaseInitialization function returns shared connection to couchbase.
+// Connection is created during first call and reused until it goes offline, then new connection is created.
+func (cc *Connection) CouchbaseInitialization(c context.Context) (*Configuration, diag.Diagnostics) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	c = cc.logContext(c, logSubsystemConnection)
 
-	cluster, diags := cc.ConnectionValidate()
+	if cc.configuration != nil {
+		if cc.configuration.isOnline() {
+			return cc.configuration, nil
+		}
+		tflog.SubsystemWarn(c, logSubsystemConnection, "shared couchbase connection is offline, reconnecting")
+		cc.configuration.ConnectionCLose()
+		cc.configuration = nil
+	}
+
+	cluster, diags := cc.ConnectionValidate(c)
+	if diags != nil {
+		return nil, diags
+	}
+
+	cc.configuration = &Configuration{
+		Cluster:       &gocbCluster{Cluster: cluster},
+		BucketManager: cluster.Buckets(),
+		UserManager:   cluster.Users(),
+		QueryIndexManager: &gocbQueryIndexManager{
+			cluster: cluster,
+			indexes: cluster.QueryIndexes(),
+		},
+		Management: cc.management(),
+	}
 
-	return &Configuration{
-		Cluster:           cluster,
-		BucketManager:     cluster.Buckets(),
-		UserManager:       cluster.Users(),
-		QueryIndexManager: cluster.QueryIndexes(),
-	}, diags
+	return cc.configuration, nil
 }
 
 // ConnectionValidate function validates connection to couchbase
-func (cc *Connection) ConnectionValidate() (*gocb.Cluster, diag.Diagnostics) {
+func (cc *Connection) ConnectionValidate(c context.Context) (*gocb.Cluster, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
-	cbAddress := fmt.Sprintf("%s://%s:%d", cc.Scheme, cc.Address, cc.NodePort)
+	cbAddress := cc.connectionString()
+	start := time.Now()
+
+	tflog.SubsystemDebug(c, logSubsystemConnection, "connecting to couchbase", map[string]interface{}{
+		"connection_string": cbAddress,
+		"network":           cc.Network,
+		"username":          cc.ClusterOptions.Username,
+		"certificate_auth":  cc.ClusterOptions.Authenticator != nil,
+		"tls":               cc.Scheme == "couchbases",
+	})
 
 	cluster, err := gocb.Connect(cbAddress, cc.ClusterOptions)
 	if err != nil {
+		tflog.SubsystemError(c, logSubsystemConnection, "cannot connect to couchbase", map[string]interface{}{
+			"connection_string": cbAddress,
+			"error":             err.Error(),
+		})
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  fmt.Sprintf("cannot connect to couchbase %s\n", cbAddress),
@@ -58,9 +135,118 @@ func (cc *Connection) ConnectionValidate() (*gocb.Cluster, diag.Diagnostics) {
 		})
 		return nil, diags
 	}
+
+	// Wait for bootstrap so concurrent callers don't see connection which is still offline
+	err = cluster.WaitUntilReady(cc.ClusterOptions.TimeoutsConfig.ManagementTimeout, &gocb.WaitUntilReadyOptions{
+		ServiceTypes: []gocb.ServiceType{gocb.ServiceTypeManagement},
+	})
+	if err != nil {
+		tflog.SubsystemError(c, logSubsystemConnection, "couchbase is not ready", map[string]interface{}{
+			"connection_string": cbAddress,
+			"duration":          time.Since(start).String(),
+			"error":             err.Error(),
+		})
+		_ = cluster.Close(nil)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("couchbase %s is not ready\n", cbAddress),
+			Detail:   fmt.Sprintf("error details: %s\n", err),
+		})
+		return nil, diags
+	}
+
+	tflog.SubsystemInfo(c, logSubsystemConnection, "connected to couchbase", map[string]interface{}{
+		"connection_string": cbAddress,
+		"duration":          time.Since(start).String(),
+	})
+
 	return cluster, diags
 }
 
+// connectionString function returns connection string for gocb bootstrap. Connection string from provider
+// has priority before address and node port. Network mode other than auto is passed to gocb as connection string option
+func (cc *Connection) connectionString() string {
+	if cc.ConnectionString == "" {
+		if cc.Network == "" || cc.Network == networkAuto {
+			return fmt.Sprintf("%s://%s:%d", cc.Scheme, cc.Address, cc.NodePort)
+		}
+		return fmt.Sprintf("%s://%s:%d?network=%s", cc.Scheme, cc.Address, cc.NodePort, cc.Network)
+	}
+
+	spec, err := connstr.Parse(cc.ConnectionString)
+	if err != nil || cc.Network == "" || cc.Network == networkAuto {
+		return cc.ConnectionString
+	}
+
+	if spec.Options == nil {
+		spec.Options = map[string][]string{}
+	}
+	spec.Options["network"] = []string{cc.Network}
+
+	return spec.String()
+}
+
+// seedHosts function returns hosts of seed nodes which are used for management REST API requests
+func (cc *Connection) seedHosts() []string {
+	if cc.ConnectionString == "" {
+		return []string{cc.Address}
+	}
+
+	spec, err := connstr.Parse(cc.ConnectionString)
+	if err != nil || len(spec.Addresses) == 0 {
+		return []string{cc.Address}
+	}
+
+	hosts := make([]string, 0, len(spec.Addresses))
+	for _, address := range spec.Addresses {
+		hosts = append(hosts, address.Host)
+	}
+
+	return hosts
+}
+
+// management function returns shared couchbase REST API client which is created during first use
+func (cc *Connection) management() managementAPI {
+	cc.managementOnce.Do(func() {
+		if cc.managementClient == nil {
+			cc.managementClient = newManagementClient(cc)
+		}
+	})
+
+	return cc.managementClient
+}
+
+// Close function closes shared couchbase connection
+func (cc *Connection) Close() {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if cc.configuration != nil {
+		cc.configuration.ConnectionCLose()
+		cc.configuration = nil
+	}
+
+	if cc.tracerProvider != nil {
+		_ = cc.tracerProvider.Shutdown(context.Background())
+	}
+
+	// File is closed after shutdown so spans flushed by exporter are written
+	if cc.tracerOutput != nil {
+		_ = cc.tracerOutput.Close()
+		cc.tracerOutput = nil
+	}
+}
+
+// isOnline function checks local SDK state of cluster connection
+func (cc *Configuration) isOnline() bool {
+	report, err := cc.Cluster.Diagnostics(nil)
+	if err != nil {
+		return false
+	}
+
+	return report.State != gocb.ClusterStateOffline
+}
+
 // ConnectionCLose close couchbase connection
 func (cc *Configuration) ConnectionCLose() {
 	defer func() {
@@ -6,6 +6,7 @@ const (
 
 	// Provider variables
 	providerAddress               = "address"
+	providerConnectionString      = "connection_string"
 	providerClientPort            = "client_port"
 	providerNodePort              = "node_port"
 	providerUsername              = "username"
@@ -14,6 +15,24 @@ const (
 	providerTLSRootCertSkipVerify = "tls_root_cert_skip_verify"
 	providerAllowSaslMechanism    = "allow_sasl_mechanism"
 	providerTLSRootCert           = "tls_root_cert"
+	providerTLSRootCertPEM        = "tls_root_cert_pem"
+	providerTLSRootCertSystemPool = "tls_root_cert_system_pool"
+	providerClientCert            = "client_cert"
+	providerClientKey             = "client_key"
+	providerNetwork               = "network"
+	providerSDKLogging            = "sdk_logging"
+	providerDeletionProtection    = "deletion_protection"
+	providerTracing               = "tracing"
+	providerTracingExporter       = "exporter"
+	providerTracingEndpoint       = "endpoint"
+	providerTracingInsecure       = "insecure"
+	providerTracingFile           = "file"
+	providerTracingServiceName    = "service_name"
+	providerRetry                 = "retry"
+	providerRetryMaxAttempts      = "max_attempts"
+	providerRetryBackoff          = "backoff"
+	providerRetryJitter           = "jitter"
+	providerRetryMaxElapsed       = "max_elapsed"
 
 	// Bucket resource constants, contents
 	keyBucketName                   = "name"
@@ -29,6 +48,28 @@ const (
 	keyBucketDurabilityLevel        = "durability_level"
 	keyBucketStorageBackend         = "storage_backend"
 
+	keyBucketHistoryRetentionCollectionDefault = "history_retention_collection_default"
+	keyBucketHistoryRetentionBytes             = "history_retention_bytes"
+	keyBucketHistoryRetentionDuration          = "history_retention_duration"
+	keyBucketAutoCompaction                    = "auto_compaction"
+	keyBucketDeletionProtection                = "deletion_protection"
+	keyBucketAllowDestroyIfEmpty               = "allow_destroy_if_empty"
+
+	// Bucket auto compaction constants, contents
+	keyAutoCompactionDatabaseFragmentationPercentage = "database_fragmentation_threshold_percentage"
+	keyAutoCompactionDatabaseFragmentationSize       = "database_fragmentation_threshold_size"
+	keyAutoCompactionViewFragmentationPercentage     = "view_fragmentation_threshold_percentage"
+	keyAutoCompactionViewFragmentationSize           = "view_fragmentation_threshold_size"
+	keyAutoCompactionMagmaFragmentationPercentage    = "magma_fragmentation_percentage"
+	keyAutoCompactionAllowedTimePeriod               = "allowed_time_period"
+	keyAutoCompactionParallelCompaction              = "parallel_compaction"
+	keyAutoCompactionPurgeInterval                   = "purge_interval"
+	keyAutoCompactionFromHour                        = "from_hour"
+	keyAutoCompactionFromMinute                      = "from_minute"
+	keyAutoCompactionToHour                          = "to_hour"
+	keyAutoCompactionToMinute                        = "to_minute"
+	keyAutoCompactionAbortOutside                    = "abort_outside"
+
 	// Security group resource constants, contents
 	keySecurityGroupName           = "name"
 	keySecurityGroupDescription    = "description"
@@ -47,33 +88,81 @@ const (
 	keySecurityUserGroup       = "groups"
 
 	// Primary query index resource constants, contents
-	keyPrimaryQueryIndexName       = "name"
-	keyPrimaryQueryIndexBucket     = "bucket"
-	keyPrimaryQueryIndexNumReplica = "num_replica"
+	keyPrimaryQueryIndexName         = "name"
+	keyPrimaryQueryIndexBucket       = "bucket"
+	keyPrimaryQueryIndexNumReplica   = "num_replica"
+	keyPrimaryQueryIndexDeferred     = "deferred"
+	keyPrimaryQueryIndexScope        = "scope"
+	keyPrimaryQueryIndexCollection   = "collection"
+	keyPrimaryQueryIndexNodes        = "nodes"
+	keyPrimaryQueryIndexNumPartition = "num_partition"
+	keyPrimaryQueryIndexStatus       = "status"
+	keyPrimaryQueryIndexHosts        = "hosts"
+	keyPrimaryQueryIndexProgress     = "progress"
 
 	// Query index resource constants, contents
-	keyQueryIndexName       = "name"
-	keyQueryIndexBucket     = "bucket"
-	keyQueryIndexNumReplica = "num_replica"
-	keyQueryIndexFields     = "fields"
-	keyQueryIndexCondition  = "condition"
+	keyQueryIndexName         = "name"
+	keyQueryIndexBucket       = "bucket"
+	keyQueryIndexNumReplica   = "num_replica"
+	keyQueryIndexFields       = "fields"
+	keyQueryIndexCondition    = "condition"
+	keyQueryIndexDeferred     = "deferred"
+	keyQueryIndexScope        = "scope"
+	keyQueryIndexCollection   = "collection"
+	keyQueryIndexPartitionBy  = "partition_by"
+	keyQueryIndexNumPartition = "num_partition"
+	keyQueryIndexNodes        = "nodes"
+	keyQueryIndexStatus       = "status"
+	keyQueryIndexHosts        = "hosts"
+	keyQueryIndexProgress     = "progress"
+
+	// Query index build resource constants, contents
+	keyQueryIndexBuildBucket  = "bucket"
+	keyQueryIndexBuildIndexes = "index_ids"
+
+	// Alternate address resource constants
+	keyAlternateAddressNode     = "node"
+	keyAlternateAddressHostname = "hostname"
+	keyAlternateAddressPorts    = "ports"
 
 	// Scope resource constants
-	keyScopeName       = "name"
-	keyScopeBucketName = "bucket"
+	keyScopeName                = "name"
+	keyScopeBucketName          = "bucket"
+	keyScopeDeletionProtection  = "deletion_protection"
+	keyScopeAllowDestroyIfEmpty = "allow_destroy_if_empty"
 
 	// Collection resource constants
-	keyCollectionName       = "name"
-	keyCollectionScopeName  = "scope"
-	keyCollectionBucketName = "bucket"
-	keyCollectionMaxExpiry  = "max_expire"
-	keyCollectionHistory    = "history"
+	keyCollectionName                = "name"
+	keyCollectionScopeName           = "scope"
+	keyCollectionBucketName          = "bucket"
+	keyCollectionMaxExpiry           = "max_expire"
+	keyCollectionHistory             = "history"
+	keyCollectionDeletionProtection  = "deletion_protection"
+	keyCollectionAllowDestroyIfEmpty = "allow_destroy_if_empty"
+
+	// Cluster version data source constants
+	keyClusterVersionVersion    = "version"
+	keyClusterVersionMajor      = "major"
+	keyClusterVersionMinor      = "minor"
+	keyClusterVersionPatch      = "patch"
+	keyClusterVersionBuild      = "build"
+	keyClusterVersionEnterprise = "enterprise"
 
 	// Others
-	queryIndexTimeoutCreate    = 300
-	bucketTimeoutCreate        = 300
-	scopeTimeoutCreate         = 300
-	collectionTimeoutCreate    = 300
-	securityUserTimeoutCreate  = 300
-	securityGroupTimeoutCreate = 300
+	retryMaxAttempts = 5
+	retryBackoff     = 500
+	retryMaxBackoff  = 10
+	retryMaxElapsed  = 120
+
+	// Time in seconds for which indexer status is shared by reads of query indexes
+	indexStatusCacheTTL = 5
+
+	// Minimal history retention size of magma bucket (2 GiB)
+	minHistoryRetentionBytes = 2147483648
+	// Minimal ram quota of magma bucket in MiB before Couchbase 8.0
+	minMagmaRAMQuota = 1024
+	// Maximal number of bucket replicas supported by durable writes
+	maxDurableReplicas = 2
+	// Number of bytes in MiB used by ram quota
+	mebibyte = 1024 * 1024
 )
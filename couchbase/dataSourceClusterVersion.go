@@ -0,0 +1,91 @@
+package couchbase
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceClusterVersion() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: readClusterVersion,
+		Description: "Couchbase server version of cluster",
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			keyClusterVersionVersion: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Couchbase server version in major.minor.patch format",
+			},
+			keyClusterVersionMajor: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Major version",
+			},
+			keyClusterVersionMinor: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Minor version",
+			},
+			keyClusterVersionPatch: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Patch version",
+			},
+			keyClusterVersionBuild: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Build number and edition",
+			},
+			keyClusterVersionEnterprise: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Enterprise edition",
+			},
+		},
+	}
+}
+
+func readClusterVersion(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	c, cancel := context.WithTimeout(c, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	version, err := m.(*Connection).clusterVersion(c)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(version.String())
+
+	if err := d.Set(keyClusterVersionVersion, version.String()); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionVersion, version.String(), err))
+	}
+
+	if err := d.Set(keyClusterVersionMajor, version.Major); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionMajor, version.Major, err))
+	}
+
+	if err := d.Set(keyClusterVersionMinor, version.Minor); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionMinor, version.Minor, err))
+	}
+
+	if err := d.Set(keyClusterVersionPatch, version.Patch); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionPatch, version.Patch, err))
+	}
+
+	if err := d.Set(keyClusterVersionBuild, version.Build); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionBuild, version.Build, err))
+	}
+
+	if err := d.Set(keyClusterVersionEnterprise, version.Enterprise); err != nil {
+		diags = append(diags, *diagForValueSet(keyClusterVersionEnterprise, version.Enterprise, err))
+	}
+
+	return diags
+}
@@ -0,0 +1,97 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// deletionGuard struct contains deletion protection attributes of resource which holds data
+type deletionGuard struct {
+	resource   string
+	protection string
+	allowEmpty string
+}
+
+// deletionProtectionSchema function returns deletion protection attribute. Provider deletion protection is used when
+// attribute isn't configured
+func deletionProtectionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Computed:    true,
+		Description: "Prevent destroy and replacement of resource. Provider deletion_protection is used when it isn't set",
+	}
+}
+
+// allowDestroyIfEmptySchema function returns attribute which allows destroy of protected resource without data
+func allowDestroyIfEmptySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Allow destroy and replacement of protected resource when bucket doesn't contain any items",
+	}
+}
+
+// customizeDiff function sets provider deletion protection when resource doesn't configure it and rejects replacement
+// of protected resource during plan. Protected resource which allows destroy when it's empty is checked during apply
+func (g deletionGuard) customizeDiff(d *schema.ResourceDiff, cc *Connection, attributes map[string]*schema.Schema) error {
+	if configAttribute(d.GetRawConfig(), g.protection).IsNull() {
+		if err := d.SetNew(g.protection, cc.DeletionProtection); err != nil {
+			return err
+		}
+	}
+
+	if d.Id() == "" || !requiresReplacement(d, attributes) {
+		return nil
+	}
+
+	// Replacement is rejected when protection is enabled in state or in plan because delete uses state value
+	oldProtection, newProtection := d.GetChange(g.protection)
+	if (!oldProtection.(bool) && !newProtection.(bool)) || d.Get(g.allowEmpty).(bool) {
+		return nil
+	}
+
+	return fmt.Errorf("%s %s is protected by %s and cannot be replaced, set %s = false and apply it before replacement",
+		g.resource, d.Id(), g.protection, g.protection)
+}
+
+// checkDelete function returns error when protected resource can't be destroyed. Protected resource which allows
+// destroy when it's empty is destroyed only when bucket doesn't contain any items, because bucket stats don't contain
+// item count of scopes and collections
+func (g deletionGuard) checkDelete(c context.Context, d *schema.ResourceData, couchbase *Configuration, bucketName string) error {
+	if !d.Get(g.protection).(bool) {
+		return nil
+	}
+
+	if !d.Get(g.allowEmpty).(bool) {
+		return fmt.Errorf("%s %s is protected by %s, set %s = false and apply it before destroy",
+			g.resource, d.Id(), g.protection, g.protection)
+	}
+
+	items, err := couchbase.getBucketItemCount(c, bucketName)
+	if err != nil {
+		return fmt.Errorf("cannot check that protected %s %s is empty: %s", g.resource, d.Id(), err)
+	}
+
+	if items > 0 {
+		return fmt.Errorf("%s %s is protected by %s and isn't empty, bucket %s contains %d items",
+			g.resource, d.Id(), g.protection, bucketName, items)
+	}
+
+	return nil
+}
+
+// importState function imports resource with provider deletion protection because protection isn't stored in couchbase
+func (g deletionGuard) importState(_ context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set(g.protection, m.(*Connection).DeletionProtection); err != nil {
+		return nil, err
+	}
+	if err := d.Set(g.allowEmpty, false); err != nil {
+		return nil, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
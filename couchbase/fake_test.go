@@ -0,0 +1,896 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/go-cty/cty"
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// fakeCluster struct is in-memory couchbase cluster for unit tests. It implements cluster, bucket, user,
+// query index and management interfaces of Configuration
+type fakeCluster struct {
+	mutex sync.Mutex
+
+	buckets            map[string]gocb.CreateBucketSettings
+	autoCompaction     map[string]url.Values
+	itemCount          map[string]uint64
+	collections        map[string]map[string]map[string]gocb.CollectionSpec
+	users              map[string]gocb.User
+	groups             map[string]gocb.Group
+	indexes            map[string]*fakeQueryIndex
+	alterStatements    []string
+	nodes              []string
+	alternateAddresses map[string]alternateAddress
+	indexID            int
+	offline            bool
+	version            string
+	ramQuota           uint64
+
+	// building keeps created query indexes in building state until test changes their state
+	building bool
+}
+
+// fakeQueryIndex struct contains query index with settings from WITH clause
+type fakeQueryIndex struct {
+	queryIndex
+	with queryIndexWith
+	// replicas contains host of every index replica by replica ID
+	replicas map[int]string
+}
+
+// fakeCollectionManager struct implements collection manager of one fake cluster bucket
+type fakeCollectionManager struct {
+	cluster *fakeCluster
+	bucket  string
+}
+
+// newFakeCluster function creates empty fake cluster with two index nodes
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		buckets:            map[string]gocb.CreateBucketSettings{},
+		autoCompaction:     map[string]url.Values{},
+		itemCount:          map[string]uint64{},
+		collections:        map[string]map[string]map[string]gocb.CollectionSpec{},
+		users:              map[string]gocb.User{},
+		groups:             map[string]gocb.Group{},
+		indexes:            map[string]*fakeQueryIndex{},
+		nodes:              []string{"node1.example.com:8091", "node2.example.com:8091"},
+		alternateAddresses: map[string]alternateAddress{},
+		version:            "7.6.2-3721-enterprise",
+		ramQuota:           4096,
+	}
+}
+
+// newFakeConnection function creates provider connection which uses fake cluster instead of couchbase
+func newFakeConnection() (*Connection, *fakeCluster) {
+	fake := newFakeCluster()
+
+	cc := &Connection{managementClient: fake}
+	cc.configuration = &Configuration{
+		Cluster:           fake,
+		BucketManager:     fake,
+		UserManager:       fake,
+		QueryIndexManager: fake,
+		Management:        fake,
+	}
+
+	return cc, fake
+}
+
+// Diagnostics function returns state of fake cluster
+func (f *fakeCluster) Diagnostics(_ *gocb.DiagnosticsOptions) (*gocb.DiagnosticsResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.offline {
+		return &gocb.DiagnosticsResult{State: gocb.ClusterStateOffline}, nil
+	}
+	return &gocb.DiagnosticsResult{State: gocb.ClusterStateOnline}, nil
+}
+
+// Close function closes fake cluster
+func (f *fakeCluster) Close(_ *gocb.ClusterCloseOptions) error {
+	return nil
+}
+
+// Collections function returns collection manager of bucket
+func (f *fakeCluster) Collections(bucketName string) collectionManager {
+	return &fakeCollectionManager{cluster: f, bucket: bucketName}
+}
+
+// WaitUntilBucketReady function checks that bucket exists
+func (f *fakeCluster) WaitUntilBucketReady(bucketName string, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.buckets[bucketName]; !ok {
+		return gocb.ErrBucketNotFound
+	}
+	return nil
+}
+
+// CreateBucket function creates bucket with default scope and collection
+func (f *fakeCluster) CreateBucket(settings gocb.CreateBucketSettings, _ *gocb.CreateBucketOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.buckets[settings.Name]; ok {
+		return gocb.ErrBucketExists
+	}
+
+	f.buckets[settings.Name] = settings
+	f.collections[settings.Name] = map[string]map[string]gocb.CollectionSpec{
+		"_default": {"_default": {Name: "_default", ScopeName: "_default"}},
+	}
+	return nil
+}
+
+// GetBucket function returns bucket settings
+func (f *fakeCluster) GetBucket(bucketName string, _ *gocb.GetBucketOptions) (*gocb.BucketSettings, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucket, ok := f.buckets[bucketName]
+	if !ok {
+		return nil, gocb.ErrBucketNotFound
+	}
+	return &bucket.BucketSettings, nil
+}
+
+// UpdateBucket function updates bucket settings, conflict resolution type can't be changed
+func (f *fakeCluster) UpdateBucket(settings gocb.BucketSettings, _ *gocb.UpdateBucketOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucket, ok := f.buckets[settings.Name]
+	if !ok {
+		return gocb.ErrBucketNotFound
+	}
+
+	bucket.BucketSettings = settings
+	f.buckets[settings.Name] = bucket
+	return nil
+}
+
+// DropBucket function removes bucket with its collections and query indexes
+func (f *fakeCluster) DropBucket(name string, _ *gocb.DropBucketOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.buckets[name]; !ok {
+		return gocb.ErrBucketNotFound
+	}
+
+	delete(f.buckets, name)
+	delete(f.collections, name)
+	delete(f.autoCompaction, name)
+	for id, idx := range f.indexes {
+		if idx.bucketName() == name {
+			delete(f.indexes, id)
+		}
+	}
+	return nil
+}
+
+// UpsertUser function creates or updates user
+func (f *fakeCluster) UpsertUser(user gocb.User, _ *gocb.UpsertUserOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, group := range user.Groups {
+		if _, ok := f.groups[group]; !ok {
+			return fmt.Errorf("group: %s doesn't exist", group)
+		}
+	}
+
+	f.users[user.Username] = user
+	return nil
+}
+
+// GetUser function returns user without password
+func (f *fakeCluster) GetUser(name string, _ *gocb.GetUserOptions) (*gocb.UserAndMetadata, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	user, ok := f.users[name]
+	if !ok {
+		return nil, gocb.ErrUserNotFound
+	}
+
+	user.Password = ""
+	return &gocb.UserAndMetadata{User: user, Domain: gocb.LocalDomain}, nil
+}
+
+// DropUser function removes user
+func (f *fakeCluster) DropUser(name string, _ *gocb.DropUserOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.users[name]; !ok {
+		return gocb.ErrUserNotFound
+	}
+
+	delete(f.users, name)
+	return nil
+}
+
+// UpsertGroup function creates or updates group
+func (f *fakeCluster) UpsertGroup(group gocb.Group, _ *gocb.UpsertGroupOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.groups[group.Name] = group
+	return nil
+}
+
+// GetGroup function returns group
+func (f *fakeCluster) GetGroup(groupName string, _ *gocb.GetGroupOptions) (*gocb.Group, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	group, ok := f.groups[groupName]
+	if !ok {
+		return nil, gocb.ErrGroupNotFound
+	}
+	return &group, nil
+}
+
+// DropGroup function removes group
+func (f *fakeCluster) DropGroup(groupName string, _ *gocb.DropGroupOptions) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.groups[groupName]; !ok {
+		return gocb.ErrGroupNotFound
+	}
+
+	delete(f.groups, groupName)
+	return nil
+}
+
+// GetAllScopes function returns scopes of bucket sorted by name
+func (cm *fakeCollectionManager) GetAllScopes(_ *gocb.GetAllScopesOptions) ([]gocb.ScopeSpec, error) {
+	cm.cluster.mutex.Lock()
+	defer cm.cluster.mutex.Unlock()
+
+	scopes, ok := cm.cluster.collections[cm.bucket]
+	if !ok {
+		return nil, gocb.ErrBucketNotFound
+	}
+
+	var specs []gocb.ScopeSpec
+	for name, collections := range scopes {
+		spec := gocb.ScopeSpec{Name: name}
+		for _, collection := range collections {
+			spec.Collections = append(spec.Collections, collection)
+		}
+		sort.Slice(spec.Collections, func(i, j int) bool { return spec.Collections[i].Name < spec.Collections[j].Name })
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+
+	return specs, nil
+}
+
+// CreateScope function creates scope in bucket
+func (cm *fakeCollectionManager) CreateScope(scopeName string, _ *gocb.CreateScopeOptions) error {
+	cm.cluster.mutex.Lock()
+	defer cm.cluster.mutex.Unlock()
+
+	scopes, ok := cm.cluster.collections[cm.bucket]
+	if !ok {
+		return gocb.ErrBucketNotFound
+	}
+	if _, ok := scopes[scopeName]; ok {
+		return gocb.ErrScopeExists
+	}
+
+	scopes[scopeName] = map[string]gocb.CollectionSpec{}
+	return nil
+}
+
+// DropScope function removes scope with its collections
+func (cm *fakeCollectionManager) DropScope(scopeName string, _ *gocb.DropScopeOptions) error {
+	cm.cluster.mutex.Lock()
+	defer cm.cluster.mutex.Unlock()
+
+	scopes, ok := cm.cluster.collections[cm.bucket]
+	if !ok {
+		return gocb.ErrBucketNotFound
+	}
+	if _, ok := scopes[scopeName]; !ok {
+		return gocb.ErrScopeNotFound
+	}
+
+	delete(scopes, scopeName)
+	return nil
+}
+
+// CreateCollection function creates collection in scope
+func (cm *fakeCollectionManager) CreateCollection(scopeName string, collectionName string, settings *gocb.CreateCollectionSettings, _ *gocb.CreateCollectionOptions) error {
+	cm.cluster.mutex.Lock()
+	defer cm.cluster.mutex.Unlock()
+
+	scopes, ok := cm.cluster.collections[cm.bucket]
+	if !ok {
+		return gocb.ErrBucketNotFound
+	}
+	collections, ok := scopes[scopeName]
+	if !ok {
+		return gocb.ErrScopeNotFound
+	}
+	if _, ok := collections[collectionName]; ok {
+		return gocb.ErrCollectionExists
+	}
+
+	spec := gocb.CollectionSpec{Name: collectionName, ScopeName: scopeName}
+	if settings != nil {
+		spec.MaxExpiry = settings.MaxExpiry
+		spec.History = settings.History
+	}
+
+	collections[collectionName] = spec
+	return nil
+}
+
+// DropCollection function removes collection from scope
+func (cm *fakeCollectionManager) DropCollection(scopeName string, collectionName string, _ *gocb.DropCollectionOptions) error {
+	cm.cluster.mutex.Lock()
+	defer cm.cluster.mutex.Unlock()
+
+	collections, ok := cm.cluster.collections[cm.bucket][scopeName]
+	if !ok {
+		return gocb.ErrScopeNotFound
+	}
+	if _, ok := collections[collectionName]; !ok {
+		return gocb.ErrCollectionNotFound
+	}
+
+	delete(collections, collectionName)
+	return nil
+}
+
+// fakeKeyspace function returns bucket, scope and collection of query keyspace
+func fakeKeyspace(keyspace string) (string, string, string) {
+	names := strings.Split(strings.Trim(keyspace, "`"), "`.`")
+	if len(names) == 3 {
+		return names[0], names[1], names[2]
+	}
+	return names[0], "", ""
+}
+
+// findIndex function returns query index by name and keyspace. Caller must hold mutex
+func (f *fakeCluster) findIndex(indexName, bucketName, scopeName, collectionName string) *fakeQueryIndex {
+	keyspace := getKeyspace(bucketName, scopeName, collectionName)
+	for _, idx := range f.indexes {
+		if idx.Name == indexName && idx.keyspace() == keyspace {
+			return idx
+		}
+	}
+	return nil
+}
+
+// addIndex function stores new query index. Caller must hold mutex
+func (f *fakeCluster) addIndex(idx queryIndex, keyspace string, with queryIndexWith) error {
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+
+	if _, ok := f.buckets[bucketName]; !ok {
+		return fmt.Errorf("keyspace not found %s; %w", keyspace, gocb.ErrBucketNotFound)
+	}
+	if f.findIndex(idx.Name, bucketName, scopeName, collectionName) != nil {
+		return gocb.ErrIndexExists
+	}
+
+	f.indexID++
+	idx.ID = strconv.Itoa(f.indexID)
+	idx.Using = "gsi"
+	idx.State = getDeferredState(with.DeferBuild)
+	if f.building && !with.DeferBuild {
+		idx.State = "building"
+	}
+	idx.KeyspaceID = bucketName
+	if !isDefaultCollection(scopeName, collectionName) {
+		idx.BucketID = bucketName
+		idx.ScopeID = scopeName
+		idx.KeyspaceID = collectionName
+	}
+
+	index := &fakeQueryIndex{queryIndex: idx, with: with, replicas: map[int]string{}}
+	f.placeReplicas(index, with.NumReplica)
+	f.indexes[idx.ID] = index
+	return nil
+}
+
+// placeReplicas function adds or removes index replicas with the highest replica ID until index has number of replicas.
+// Replicas are placed on configured nodes first and then on nodes without another replica like indexer does.
+// Caller must hold mutex
+func (f *fakeCluster) placeReplicas(idx *fakeQueryIndex, numReplica int) {
+	used := map[string]bool{}
+	maxID := -1
+	for replicaID, host := range idx.replicas {
+		used[host] = true
+		maxID = max(maxID, replicaID)
+	}
+
+	for ; len(idx.replicas) > numReplica+1; maxID-- {
+		delete(idx.replicas, maxID)
+	}
+
+	candidates := append(append([]string{}, idx.with.Nodes...), f.nodes...)
+	for replicaID := 0; len(idx.replicas) < numReplica+1; replicaID++ {
+		if _, ok := idx.replicas[replicaID]; ok {
+			continue
+		}
+
+		host := f.nodes[replicaID%len(f.nodes)]
+		for _, node := range candidates {
+			if !used[node] {
+				host = node
+				break
+			}
+		}
+		used[host] = true
+		idx.replicas[replicaID] = host
+	}
+
+	idx.with.NumReplica = numReplica
+}
+
+func (f *fakeCluster) readQueryIndexByID(_ context.Context, id string, _ time.Duration) (*queryIndex, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	idx, ok := f.indexes[id]
+	if !ok {
+		return nil, fmt.Errorf("index not found id: %s; %w", id, gocb.ErrIndexNotFound)
+	}
+
+	result := idx.queryIndex
+	return &result, nil
+}
+
+func (f *fakeCluster) readQueryIndexByName(_ context.Context, indexName, bucketName, scopeName, collectionName string, _ time.Duration) (*queryIndex, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	idx := f.findIndex(indexName, bucketName, scopeName, collectionName)
+	if idx == nil {
+		return nil, fmt.Errorf("index not found index: %s; %w", indexName, gocb.ErrIndexNotFound)
+	}
+
+	result := idx.queryIndex
+	return &result, nil
+}
+
+func (f *fakeCluster) createPrimaryQueryIndex(_ context.Context, indexName, keyspace string, with queryIndexWith, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.addIndex(queryIndex{Name: indexName, IsPrimary: true}, keyspace, with)
+}
+
+func (f *fakeCluster) createQueryIndex(_ context.Context, indexName, keyspace string, fields []string, partitionBy []string, condition string, with queryIndexWith, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	idx := queryIndex{Name: indexName, IndexKey: fields, Condition: condition}
+	if len(partitionBy) > 0 {
+		idx.Partition = fmt.Sprintf("HASH(%s)", strings.Join(partitionBy, ","))
+	}
+
+	return f.addIndex(idx, keyspace, with)
+}
+
+// alterQueryIndexReplicaCount function changes number of replicas. Indexer moves all replicas to nodes when nodes
+// are set, otherwise existing replicas keep their placement
+func (f *fakeCluster) alterQueryIndexReplicaCount(_ context.Context, indexName, keyspace string, numReplica int, nodes []string, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+	idx := f.findIndex(indexName, bucketName, scopeName, collectionName)
+	if idx == nil {
+		return gocb.ErrIndexNotFound
+	}
+
+	f.alterStatements = append(f.alterStatements, queryIndexAlter{Action: "replica_count", NumReplica: &numReplica, Nodes: nodes}.String())
+	if len(nodes) > 0 {
+		idx.with.Nodes = nodes
+		idx.replicas = map[int]string{}
+	}
+	f.placeReplicas(idx, numReplica)
+	return nil
+}
+
+func (f *fakeCluster) dropQueryIndexReplica(_ context.Context, indexName, keyspace string, replicaID int, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+	idx := f.findIndex(indexName, bucketName, scopeName, collectionName)
+	if idx == nil {
+		return gocb.ErrIndexNotFound
+	}
+	if _, ok := idx.replicas[replicaID]; !ok {
+		return fmt.Errorf("replica %d of index %s doesn't exist", replicaID, indexName)
+	}
+
+	f.alterStatements = append(f.alterStatements, queryIndexAlter{Action: "drop_replica", ReplicaID: &replicaID}.String())
+	delete(idx.replicas, replicaID)
+	idx.with.NumReplica = len(idx.replicas) - 1
+	return nil
+}
+
+func (f *fakeCluster) buildQueryIndexes(_ context.Context, keyspace string, indexNames []string, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+	for _, name := range indexNames {
+		idx := f.findIndex(name, bucketName, scopeName, collectionName)
+		if idx == nil {
+			return gocb.ErrIndexNotFound
+		}
+		idx.State = getDeferredState(false)
+	}
+	return nil
+}
+
+func (f *fakeCluster) dropQueryIndex(_ context.Context, indexName, bucketName, scopeName, collectionName string, _ time.Duration) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if idx := f.findIndex(indexName, bucketName, scopeName, collectionName); idx != nil {
+		delete(f.indexes, idx.ID)
+	}
+	return nil
+}
+
+func (f *fakeCluster) dropPrimaryQueryIndex(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) error {
+	return f.dropQueryIndex(c, indexName, bucketName, scopeName, collectionName, timeout)
+}
+
+// fakeResponse function copies response to result through json like couchbase REST API client
+func fakeResponse(response interface{}, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// indexStatus function returns indexer status of all query index replicas. Caller must hold mutex
+func (f *fakeCluster) indexStatus() queryIndexStatusList {
+	var list queryIndexStatusList
+
+	for _, idx := range f.indexes {
+		status := "Ready"
+		if idx.State == getDeferredState(true) {
+			status = "Created"
+		}
+
+		for replica, host := range idx.replicas {
+			list.Indexes = append(list.Indexes, queryIndexStatus{
+				ID:           json.Number(idx.ID),
+				Name:         idx.Name,
+				Hosts:        []string{host},
+				Status:       status,
+				NumReplica:   idx.with.NumReplica,
+				NumPartition: idx.with.NumPartition,
+				ReplicaID:    replica,
+				Progress:     100,
+			})
+		}
+	}
+
+	return list
+}
+
+func (f *fakeCluster) get(_ context.Context, _ managementService, path string, result interface{}) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch {
+	case strings.HasPrefix(path, "/pools/default/buckets/"):
+		name, err := url.PathUnescape(strings.TrimPrefix(path, "/pools/default/buckets/"))
+		if err != nil {
+			return err
+		}
+
+		bucket, ok := f.buckets[name]
+		if !ok {
+			break
+		}
+		autoCompaction, purgeInterval := autoCompactionJSON(f.autoCompaction[name])
+		return fakeResponse(map[string]interface{}{
+			"conflictResolutionType": bucket.ConflictResolutionType,
+			"autoCompactionSettings": autoCompaction,
+			"purgeInterval":          purgeInterval,
+			"basicStats":             map[string]uint64{"itemCount": f.itemCount[name]},
+		}, result)
+	case path == "/indexStatus":
+		return fakeResponse(f.indexStatus(), result)
+	case path == "/pools":
+		return fakeResponse(clusterPools{ImplementationVersion: f.version, IsEnterprise: true}, result)
+	case path == "/pools/default":
+		var nodes []clusterNode
+		for _, node := range f.nodes {
+			nodes = append(nodes, clusterNode{Hostname: node, Services: []string{"kv", indexServiceName}})
+		}
+
+		// Cluster wide storage totals are sum of all data nodes like in couchbase older than 7.0
+		var quotaUsed uint64
+		for _, bucket := range f.buckets {
+			quotaUsed += bucket.RAMQuotaMB
+		}
+		return fakeResponse(map[string]interface{}{
+			"nodes":       nodes,
+			"memoryQuota": f.ramQuota,
+			"storageTotals": map[string]interface{}{
+				"ram": map[string]uint64{
+					"quotaTotal": f.ramQuota * mebibyte * uint64(len(f.nodes)),
+					"quotaUsed":  quotaUsed * mebibyte * uint64(len(f.nodes)),
+				},
+			},
+		}, result)
+	}
+
+	return &ErrManagementRequest{Method: http.MethodGet, Path: path, StatusCode: http.StatusNotFound}
+}
+
+func (f *fakeCluster) post(_ context.Context, _ managementService, path string, form url.Values, _ interface{}) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if strings.HasPrefix(path, "/pools/default/buckets/") {
+		name, err := url.PathUnescape(strings.TrimPrefix(path, "/pools/default/buckets/"))
+		if err != nil {
+			return err
+		}
+
+		if _, ok := f.buckets[name]; ok {
+			f.autoCompaction[name] = form
+			return nil
+		}
+	}
+
+	return &ErrManagementRequest{Method: http.MethodPost, Path: path, StatusCode: http.StatusNotFound}
+}
+
+func (f *fakeCluster) requestNode(_ context.Context, method string, host string, path string, form url.Values, result interface{}) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	found := false
+	for _, node := range f.nodes {
+		found = found || strings.HasPrefix(node, host+":")
+	}
+	if !found {
+		return fmt.Errorf("dial tcp %s: connection refused", host)
+	}
+
+	switch {
+	case method == http.MethodGet && path == "/pools/default/nodeServices":
+		var nodeServices clusterNodeServices
+		for _, node := range f.nodes {
+			hostname := strings.Split(node, ":")[0]
+			ext := clusterNodeExt{Hostname: hostname, ThisNode: hostname == host, Services: map[string]int{"mgmt": 8091}}
+			if address, ok := f.alternateAddresses[hostname]; ok {
+				ext.AlternateAddresses = map[string]alternateAddress{networkExternal: address}
+			}
+			nodeServices.NodesExt = append(nodeServices.NodesExt, ext)
+		}
+		return fakeResponse(nodeServices, result)
+	case method == http.MethodPut && path == alternateAddressPath:
+		address := alternateAddress{Hostname: form.Get("hostname"), Ports: map[string]int{}}
+		for name := range form {
+			if name == "hostname" {
+				continue
+			}
+			port, err := strconv.Atoi(form.Get(name))
+			if err != nil {
+				return &ErrManagementRequest{Method: method, Path: path, StatusCode: http.StatusBadRequest, Body: err.Error()}
+			}
+			address.Ports[name] = port
+		}
+		if len(address.Ports) == 0 {
+			address.Ports = nil
+		}
+		f.alternateAddresses[host] = address
+		return nil
+	case method == http.MethodDelete && path == alternateAddressPath:
+		delete(f.alternateAddresses, host)
+		return nil
+	}
+
+	return &ErrManagementRequest{Method: method, Path: path, StatusCode: http.StatusNotFound}
+}
+
+// testResourceApply function plans resource configuration against state and applies the plan like terraform apply
+func testResourceApply(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}) *terraform.InstanceState {
+	t.Helper()
+
+	diff := testResourceDiff(t, r, state, raw, meta)
+	if diff == nil || diff.Empty() {
+		return state
+	}
+
+	newState, diags := r.Apply(context.Background(), state, diff, meta)
+	if diags.HasError() {
+		t.Fatalf("apply failed: %v", diags)
+	}
+
+	return newState
+}
+
+// testResourceDiff function returns plan of resource configuration against state
+func testResourceDiff(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}) *terraform.InstanceDiff {
+	t.Helper()
+
+	diff, err := testResourcePlan(t, r, state, raw, meta)
+	if err != nil {
+		t.Fatalf("plan failed: %s", err)
+	}
+
+	return diff
+}
+
+// testResourcePlan function plans resource configuration against state. Raw configuration is passed in prior
+// state like terraform does, so that resources can read it with GetRawConfig
+func testResourcePlan(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}) (*terraform.InstanceDiff, error) {
+	t.Helper()
+
+	prior := &terraform.InstanceState{}
+	if state != nil {
+		prior = state.DeepCopy()
+	}
+	prior.RawConfig = testResourceRawConfig(t, r, raw)
+
+	diff, err := r.Diff(context.Background(), prior, terraform.NewResourceConfigRaw(raw), meta)
+	if diff != nil {
+		diff.RawConfig = prior.RawConfig
+	}
+
+	return diff, err
+}
+
+// testResourceRawConfig function converts resource configuration to cty value, attributes which aren't
+// configured are null
+func testResourceRawConfig(t *testing.T, r *schema.Resource, raw map[string]interface{}) cty.Value {
+	t.Helper()
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("cannot marshal configuration: %s", err)
+	}
+
+	config, err := ctyjson.Unmarshal(data, r.CoreConfigSchema().ImpliedType())
+	if err != nil {
+		t.Fatalf("cannot convert configuration: %s", err)
+	}
+
+	return config
+}
+
+// testResourceRefresh function reads resource state like terraform refresh. Nil is returned when resource
+// was removed from state
+func testResourceRefresh(t *testing.T, r *schema.Resource, state *terraform.InstanceState, meta interface{}) *terraform.InstanceState {
+	t.Helper()
+
+	newState, diags := r.RefreshWithoutUpgrade(context.Background(), state, meta)
+	if diags.HasError() {
+		t.Fatalf("refresh failed: %v", diags)
+	}
+
+	return newState
+}
+
+// testResourceDestroy function destroys resource like terraform destroy
+func testResourceDestroy(t *testing.T, r *schema.Resource, state *terraform.InstanceState, meta interface{}) {
+	t.Helper()
+
+	if _, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, meta); diags.HasError() {
+		t.Fatalf("destroy failed: %v", diags)
+	}
+}
+
+// testResourceImport function imports resource with ID like terraform import and reads its state
+func testResourceImport(t *testing.T, r *schema.Resource, id string, meta interface{}) *terraform.InstanceState {
+	t.Helper()
+
+	d := r.Data(&terraform.InstanceState{ID: id})
+	imported, err := r.Importer.StateContext(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("import failed: %s", err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("unexpected number of imported resources: %d", len(imported))
+	}
+
+	state := imported[0].State()
+	if state == nil {
+		t.Fatalf("imported resource %s doesn't have state", id)
+	}
+
+	return testResourceRefresh(t, r, state, meta)
+}
+
+// testCheckState function compares attributes of resource state with expected values
+func testCheckState(t *testing.T, state *terraform.InstanceState, expected map[string]string) {
+	t.Helper()
+
+	if state == nil || state.ID == "" {
+		t.Fatalf("resource doesn't exist")
+	}
+
+	for key, value := range expected {
+		if state.Attributes[key] != value {
+			t.Fatalf("attribute %s: expected %q, got %q", key, value, state.Attributes[key])
+		}
+	}
+}
+
+// testCheckNoDiff function checks that resource configuration doesn't produce plan after apply
+func testCheckNoDiff(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}) {
+	t.Helper()
+
+	if diff := testResourceDiff(t, r, state, raw, meta); diff != nil && !diff.Empty() {
+		t.Fatalf("unexpected plan: %v", diff)
+	}
+}
+
+// testCheckDiff function checks that resource configuration produces plan because resource drifted
+func testCheckDiff(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}) {
+	t.Helper()
+
+	if diff := testResourceDiff(t, r, state, raw, meta); diff == nil || diff.Empty() {
+		t.Fatalf("expected plan for drifted resource")
+	}
+}
+
+// testCheckPlanError function checks that resource configuration is rejected during plan with error message
+func testCheckPlanError(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}, meta interface{}, message string) {
+	t.Helper()
+
+	_, err := testResourcePlan(t, r, state, raw, meta)
+	if err == nil || !strings.Contains(err.Error(), message) {
+		t.Fatalf("expected plan error %q, got: %v", message, err)
+	}
+}
+
+// testCheckDestroyError function checks that resource destroy is rejected with error message
+func testCheckDestroyError(t *testing.T, r *schema.Resource, state *terraform.InstanceState, meta interface{}, message string) {
+	t.Helper()
+
+	_, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, meta)
+	if !diags.HasError() || !strings.Contains(fmt.Sprint(diags), message) {
+		t.Fatalf("expected destroy error %q, got: %v", message, diags)
+	}
+}
+
+// testCheckRemoved function checks that resource was removed from state
+func testCheckRemoved(t *testing.T, state *terraform.InstanceState) {
+	t.Helper()
+
+	if state != nil && state.ID != "" {
+		t.Fatalf("resource %s wasn't removed from state", state.ID)
+	}
+}
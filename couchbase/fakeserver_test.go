@@ -0,0 +1,1393 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10/memd"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const (
+	fakeServerUsername = "Administrator"
+	fakeServerPassword = "password"
+	fakeServerHost     = "127.0.0.1"
+	fakeServerVersion  = "7.6.2-3721-enterprise"
+	fakeServerRAMQuota = 4096
+)
+
+// fakeServerHelloFeatures contains key-value features which fake server accepts during HELLO
+var fakeServerHelloFeatures = []memd.HelloFeature{
+	memd.FeatureXerror,
+	memd.FeatureSelectBucket,
+}
+
+// fakeServerBucketCapabilities contains capabilities of fake server buckets in cluster configuration
+var fakeServerBucketCapabilities = []string{"collections", "durableWrite", "cccp", "xattr", "nodesExt", "couchapi"}
+
+var (
+	fakeQueryCreatePrimaryIndex = regexp.MustCompile("^CREATE PRIMARY INDEX `([^`]+)` ON (\\S+) WITH (\\{.*\\})$")
+	fakeQueryAlterIndex         = regexp.MustCompile("^ALTER INDEX `([^`]+)` ON (\\S+) WITH (\\{.*\\})$")
+	fakeQueryBuildIndex         = regexp.MustCompile("^BUILD INDEX ON ([^(]+)\\((.*)\\)$")
+	fakeQueryDropIndex          = regexp.MustCompile("^DROP INDEX `([^`]+)` ON (\\S+)$")
+	fakeQueryDropBucketIndex    = regexp.MustCompile("^DROP INDEX (`[^`]+`)\\.`([^`]+)`$")
+	fakeQueryDropPrimaryIndex   = regexp.MustCompile("^DROP PRIMARY INDEX ON (\\S+)$")
+	fakeQuerySelectIndex        = regexp.MustCompile("^SELECT `indexes`\\.\\* FROM system:indexes WHERE (.*)$")
+)
+
+// fakeServer struct is in-memory couchbase server for provider tests. Cluster management, query and indexer
+// REST API are served on one http port. Key-value port answers only bootstrap commands so gocb can connect
+// and send its management and query requests to http port
+type fakeServer struct {
+	mutex sync.Mutex
+
+	http     *httptest.Server
+	kv       net.Listener
+	httpPort int
+	kvPort   int
+
+	buckets            map[string]*fakeServerBucket
+	users              map[string]fakeServerUser
+	groups             map[string]fakeServerGroup
+	indexes            map[string]*fakeServerIndex
+	prepared           map[string]string
+	alternateAddresses map[string]alternateAddress
+	uid                int
+
+	faults   []*fakeServerFault
+	requests map[string]int
+}
+
+// fakeServerBucket struct contains bucket settings in format of couchbase REST API form and bucket collections
+type fakeServerBucket struct {
+	uuid     string
+	settings url.Values
+	scopes   map[string]map[string]fakeServerCollection
+	manifest int
+}
+
+// fakeServerCollection struct contains collection settings
+type fakeServerCollection struct {
+	maxTTL  int
+	history bool
+}
+
+// fakeServerUser struct contains local user
+type fakeServerUser struct {
+	name   string
+	roles  []jsonFakeServerRole
+	groups []string
+}
+
+// fakeServerGroup struct contains user group
+type fakeServerGroup struct {
+	description string
+	roles       []jsonFakeServerRole
+	ldap        string
+}
+
+// jsonFakeServerRole struct is role in format of couchbase REST API
+type jsonFakeServerRole struct {
+	Role       string `json:"role"`
+	Bucket     string `json:"bucket_name,omitempty"`
+	Scope      string `json:"scope_name,omitempty"`
+	Collection string `json:"collection_name,omitempty"`
+}
+
+// fakeServerIndex struct contains query index and its settings from WITH clause
+type fakeServerIndex struct {
+	queryIndex
+	with queryIndexWith
+}
+
+// fakeServerFault struct describes error response or delay which is injected to requests with method and path prefix.
+// After is number of matching requests which are served before fault. Count is number of affected requests,
+// fault without count affects all requests
+type fakeServerFault struct {
+	method string
+	path   string
+	status int
+	body   string
+	delay  time.Duration
+	after  int
+	count  int
+}
+
+// fakeServerStatus struct is successful response of fake server with status other than 200 OK
+type fakeServerStatus struct {
+	status int
+	body   interface{}
+}
+
+// fakeServerError struct is error response of fake server
+type fakeServerError struct {
+	status int
+	body   interface{}
+}
+
+// Error function returns response body of fake server error
+func (e *fakeServerError) Error() string {
+	return fmt.Sprintf("%d %v", e.status, e.body)
+}
+
+// newFakeServer function starts fake couchbase server which is stopped at the end of test
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{
+		buckets:            map[string]*fakeServerBucket{},
+		users:              map[string]fakeServerUser{},
+		groups:             map[string]fakeServerGroup{},
+		indexes:            map[string]*fakeServerIndex{},
+		prepared:           map[string]string{},
+		alternateAddresses: map[string]alternateAddress{},
+		requests:           map[string]int{},
+		uid:                1000,
+	}
+
+	kv, err := net.Listen("tcp", net.JoinHostPort(fakeServerHost, "0"))
+	if err != nil {
+		t.Fatalf("cannot start fake key-value service: %s", err)
+	}
+	s.kv = kv
+	s.kvPort = kv.Addr().(*net.TCPAddr).Port
+	go s.serveKV()
+
+	s.http = httptest.NewServer(s)
+	s.httpPort = s.http.Listener.Addr().(*net.TCPAddr).Port
+
+	t.Cleanup(func() {
+		s.http.Close()
+		_ = s.kv.Close()
+	})
+
+	return s
+}
+
+// settings function returns raw provider settings which connect provider to fake server
+func (s *fakeServer) settings() map[string]interface{} {
+	return map[string]interface{}{
+		providerAddress:            fakeServerHost,
+		providerClientPort:         s.httpPort,
+		providerNodePort:           s.kvPort,
+		providerUsername:           fakeServerUsername,
+		providerPassword:           fakeServerPassword,
+		providerConnectionTimeout:  10,
+		providerAllowSaslMechanism: "PLAIN",
+		providerRetry: []interface{}{
+			map[string]interface{}{
+				providerRetryMaxAttempts: 5,
+				providerRetryBackoff:     10,
+				providerRetryJitter:      false,
+			},
+		},
+	}
+}
+
+// providerConfig function returns terraform provider block which connects provider to fake server
+func (s *fakeServer) providerConfig() string {
+	return fmt.Sprintf(`
+provider "couchbase" {
+    address              = "%s"
+    client_port          = %d
+    node_port            = %d
+    username             = "%s"
+    password             = "%s"
+    allow_sasl_mechanism = "PLAIN"
+
+    retry {
+        max_attempts = 5
+        backoff      = 10
+        jitter       = false
+    }
+}
+`, fakeServerHost, s.httpPort, s.kvPort, fakeServerUsername, fakeServerPassword)
+}
+
+// connection function configures provider with raw settings and returns its connection
+func (s *fakeServer) connection(t *testing.T, settings map[string]interface{}) *Connection {
+	t.Helper()
+
+	p := Provider()
+	if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(settings)); diags.HasError() {
+		t.Fatalf("cannot configure provider: %v", diags)
+	}
+
+	cc := p.Meta().(*Connection)
+	t.Cleanup(cc.Close)
+
+	return cc
+}
+
+// providerFactories function returns provider factories for terraform test steps against fake server
+func (s *fakeServer) providerFactories() map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"couchbase": func() (*schema.Provider, error) {
+			return Provider(), nil
+		},
+	}
+}
+
+// inject function adds fault to requests which match method and path prefix
+func (s *fakeServer) inject(fault fakeServerFault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// requestCount function returns number of received requests with method and path
+func (s *fakeServer) requestCount(method, path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[method+" "+path]
+}
+
+// nextUID function returns unique identifier for buckets, scopes, collections and indexes. Caller must hold mutex
+func (s *fakeServer) nextUID() int {
+	s.uid++
+	return s.uid
+}
+
+// serveKV function accepts key-value connections until listener is closed
+func (s *fakeServer) serveKV() {
+	for {
+		conn, err := s.kv.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleKV(conn)
+	}
+}
+
+// handleKV function answers bootstrap commands of one key-value connection
+func (s *fakeServer) handleKV(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	mc := memd.NewConn(conn)
+	bucket := ""
+
+	for {
+		req, _, err := mc.ReadPacket()
+		if err != nil {
+			return
+		}
+
+		res := &memd.Packet{
+			Magic:   memd.CmdMagicRes,
+			Command: req.Command,
+			Opaque:  req.Opaque,
+		}
+
+		switch req.Command {
+		case memd.CmdHello:
+			res.Key = req.Key
+			res.Value = helloFeatures(req.Value)
+		case memd.CmdSASLListMechs:
+			res.Value = []byte("PLAIN")
+		case memd.CmdSASLAuth:
+			if string(req.Key) != "PLAIN" || string(req.Value) != "\x00"+fakeServerUsername+"\x00"+fakeServerPassword {
+				res.Status = memd.StatusAuthError
+			}
+		case memd.CmdSelectBucket:
+			s.mutex.Lock()
+			_, ok := s.buckets[string(req.Key)]
+			s.mutex.Unlock()
+
+			if ok {
+				bucket = string(req.Key)
+			} else {
+				res.Status = memd.StatusAccessError
+			}
+		case memd.CmdGetClusterConfig:
+			res.Value = s.clusterConfig(bucket)
+		case memd.CmdNoop:
+		default:
+			res.Status = memd.StatusUnknownCommand
+		}
+
+		if err := mc.WritePacket(res); err != nil {
+			return
+		}
+	}
+}
+
+// helloFeatures function returns requested HELLO features which are supported by fake server
+func helloFeatures(requested []byte) []byte {
+	var features []byte
+
+	for i := 0; i+1 < len(requested); i += 2 {
+		feature := memd.HelloFeature(binary.BigEndian.Uint16(requested[i:]))
+		for _, supported := range fakeServerHelloFeatures {
+			if feature == supported {
+				features = binary.BigEndian.AppendUint16(features, uint16(feature))
+			}
+		}
+	}
+
+	return features
+}
+
+// nodeServices function returns services of fake server node. Caller must hold mutex
+func (s *fakeServer) nodeServices() map[string]interface{} {
+	node := map[string]interface{}{
+		"hostname": fakeServerHost,
+		"thisNode": true,
+		"services": map[string]int{
+			"mgmt":      s.httpPort,
+			"kv":        s.kvPort,
+			"n1ql":      s.httpPort,
+			"indexHttp": s.httpPort,
+		},
+	}
+
+	if address, ok := s.alternateAddresses[fakeServerHost]; ok {
+		node["alternateAddresses"] = map[string]alternateAddress{networkExternal: address}
+	}
+
+	return node
+}
+
+// clusterConfig function returns cluster configuration which is sent to gocb. Configuration of selected bucket
+// contains vbucket map with fake server node
+func (s *fakeServer) clusterConfig(bucketName string) []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	config := map[string]interface{}{
+		"rev":                    1,
+		"nodesExt":               []interface{}{s.nodeServices()},
+		"clusterCapabilitiesVer": []int{1, 0},
+		"clusterCapabilities":    map[string][]string{"n1ql": {"enhancedPreparedStatements"}},
+	}
+
+	if bucket, ok := s.buckets[bucketName]; ok {
+		vbuckets := make([][]int, 64)
+		for i := range vbuckets {
+			vbuckets[i] = []int{0}
+		}
+
+		capabilities := fakeServerBucketCapabilities
+		if bucket.settings.Get("storageBackend") == "magma" {
+			capabilities = append(capabilities, "nonDedupedHistory")
+		}
+
+		config["name"] = bucketName
+		config["uuid"] = bucket.uuid
+		config["nodeLocator"] = "vbucket"
+		config["nodes"] = []interface{}{
+			map[string]interface{}{
+				"hostname": net.JoinHostPort(fakeServerHost, strconv.Itoa(s.httpPort)),
+				"ports":    map[string]int{"direct": s.kvPort},
+			},
+		}
+		config["bucketCapabilitiesVer"] = ""
+		config["bucketCapabilities"] = capabilities
+		config["vBucketServerMap"] = map[string]interface{}{
+			"hashAlgorithm": "CRC",
+			"numReplicas":   0,
+			"serverList":    []string{net.JoinHostPort(fakeServerHost, strconv.Itoa(s.kvPort))},
+			"vBucketMap":    vbuckets,
+		}
+	}
+
+	// Marshal of maps with strings and numbers can't fail
+	data, _ := json.Marshal(config)
+	return data
+}
+
+// fault function returns injected fault which matches request. Caller must hold mutex
+func (s *fakeServer) fault(method, path string) *fakeServerFault {
+	for i, fault := range s.faults {
+		if fault.method != method || !strings.HasPrefix(path, fault.path) {
+			continue
+		}
+
+		if fault.after > 0 {
+			fault.after--
+			return nil
+		}
+
+		if fault.count > 0 {
+			fault.count--
+			if fault.count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+
+	return nil
+}
+
+// ServeHTTP function handles REST API requests of cluster management, query and indexer services
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	s.requests[r.Method+" "+r.URL.Path]++
+	fault := s.fault(r.Method, r.URL.Path)
+	s.mutex.Unlock()
+
+	if fault != nil {
+		select {
+		case <-time.After(fault.delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		if fault.status != 0 {
+			w.WriteHeader(fault.status)
+			_, _ = w.Write([]byte(fault.body))
+			return
+		}
+	}
+
+	if username, password, ok := r.BasicAuth(); !ok || username != fakeServerUsername || password != fakeServerPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	response, err := s.route(r.Method, r.URL.Path, form, body)
+	s.mutex.Unlock()
+
+	status := http.StatusOK
+	if serverStatus, ok := response.(*fakeServerStatus); ok {
+		status = serverStatus.status
+		response = serverStatus.body
+	}
+	if serverErr, ok := err.(*fakeServerError); ok {
+		status = serverErr.status
+		response = serverErr.body
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if text, ok := response.(string); ok {
+		_, _ = w.Write([]byte(text))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// route function dispatches REST API request to fake server handler. Caller must hold mutex
+func (s *fakeServer) route(method, path string, form url.Values, body []byte) (interface{}, error) {
+	names := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case path == "/" || path == "/admin/ping":
+		return map[string]interface{}{}, nil
+	case method == http.MethodGet && path == "/whoami":
+		return map[string]interface{}{"id": fakeServerUsername, "domain": "admin", "roles": []jsonFakeServerRole{{Role: "admin"}}}, nil
+	case method == http.MethodGet && path == "/pools":
+		return s.getPools(), nil
+	case method == http.MethodGet && path == "/pools/default":
+		return s.getPoolsDefault(), nil
+	case method == http.MethodGet && path == "/pools/default/nodeServices":
+		return map[string]interface{}{"rev": 1, "nodesExt": []interface{}{s.nodeServices()}}, nil
+	case method == http.MethodPost && path == "/pools/default/buckets":
+		return s.createBucket(form)
+	case strings.HasPrefix(path, "/pools/default/buckets/") && len(names) == 4:
+		return s.bucket(method, names[3], form)
+	case strings.HasPrefix(path, "/pools/default/buckets/") && len(names) >= 5 && names[4] == "scopes":
+		return s.collections(method, names[3], names[5:], form)
+	case strings.HasPrefix(path, "/settings/rbac/users/local/") && len(names) == 5:
+		return s.user(method, names[4], form)
+	case strings.HasPrefix(path, "/settings/rbac/groups/") && len(names) == 4:
+		return s.group(method, names[3], form)
+	case method == http.MethodGet && path == "/indexStatus":
+		return s.indexStatus(), nil
+	case method == http.MethodPost && path == "/query/service":
+		return s.query(body)
+	case path == alternateAddressPath:
+		return s.alternateAddress(method, form)
+	}
+
+	return nil, &fakeServerError{status: http.StatusNotFound, body: "Not found."}
+}
+
+// getPools function returns cluster information with version of fake server
+func (s *fakeServer) getPools() map[string]interface{} {
+	return map[string]interface{}{
+		"isAdminCreds":          true,
+		"isEnterprise":          true,
+		"implementationVersion": fakeServerVersion,
+		"pools":                 []interface{}{map[string]string{"name": "default", "uri": "/pools/default"}},
+	}
+}
+
+// getPoolsDefault function returns cluster nodes and storage totals. Caller must hold mutex
+func (s *fakeServer) getPoolsDefault() map[string]interface{} {
+	quotaUsed := 0
+	for _, bucket := range s.buckets {
+		quota, _ := strconv.Atoi(bucket.settings.Get("ramQuotaMB"))
+		quotaUsed += quota
+	}
+
+	return map[string]interface{}{
+		"name":        "default",
+		"memoryQuota": fakeServerRAMQuota,
+		"nodes": []interface{}{
+			map[string]interface{}{
+				"hostname": net.JoinHostPort(fakeServerHost, strconv.Itoa(s.httpPort)),
+				"services": []string{"kv", "n1ql", indexServiceName},
+				"version":  fakeServerVersion,
+				"thisNode": true,
+			},
+		},
+		"storageTotals": map[string]interface{}{
+			"ram": map[string]interface{}{
+				"quotaTotal":        fakeServerRAMQuota * 1024 * 1024,
+				"quotaUsed":         quotaUsed * 1024 * 1024,
+				"quotaTotalPerNode": fakeServerRAMQuota * 1024 * 1024,
+				"quotaUsedPerNode":  quotaUsed * 1024 * 1024,
+			},
+		},
+	}
+}
+
+// settingsJSON function returns bucket in format of couchbase REST API
+func (b *fakeServerBucket) settingsJSON(name string) map[string]interface{} {
+	get := func(key, value string) string {
+		if b.settings.Has(key) {
+			return b.settings.Get(key)
+		}
+		return value
+	}
+	number := func(key, value string) int {
+		n, _ := strconv.Atoi(get(key, value))
+		return n
+	}
+
+	controllers := map[string]string{}
+	if get("flushEnabled", "0") == "1" {
+		controllers["flush"] = fmt.Sprintf("/pools/default/buckets/%s/controller/doFlush", url.PathEscape(name))
+	}
+
+	bucketType := get("bucketType", "membase")
+	if bucketType == "couchbase" {
+		bucketType = "membase"
+	}
+
+	evictionPolicy := "valueOnly"
+	if bucketType == "ephemeral" {
+		evictionPolicy = "noEviction"
+	}
+
+	ram := number("ramQuotaMB", "100") * 1024 * 1024
+
+	bucket := map[string]interface{}{
+		"name":                   name,
+		"uuid":                   b.uuid,
+		"bucketType":             bucketType,
+		"controllers":            controllers,
+		"replicaIndex":           get("replicaIndex", "1") == "1",
+		"quota":                  map[string]int{"ram": ram, "rawRAM": ram},
+		"replicaNumber":          number("replicaNumber", "1"),
+		"evictionPolicy":         get("evictionPolicy", evictionPolicy),
+		"maxTTL":                 number("maxTTL", "0"),
+		"compressionMode":        get("compressionMode", "passive"),
+		"durabilityMinLevel":     get("durabilityMinLevel", "none"),
+		"conflictResolutionType": get("conflictResolutionType", "seqno"),
+		"basicStats":             map[string]int{"itemCount": 0},
+	}
+
+	if bucketType == "membase" {
+		bucket["storageBackend"] = get("storageBackend", "couchstore")
+	}
+
+	if bucketType == "membase" {
+		bucket["autoCompactionSettings"], bucket["purgeInterval"] = autoCompactionJSON(b.settings)
+	}
+
+	if bucket["storageBackend"] == "magma" {
+		bucket["historyRetentionCollectionDefault"] = get("historyRetentionCollectionDefault", "true") == "true"
+		bucket["historyRetentionBytes"] = number("historyRetentionBytes", "0")
+		bucket["historyRetentionSeconds"] = number("historyRetentionSeconds", "0")
+	}
+
+	return bucket
+}
+
+// autoCompactionJSON function returns auto compaction settings and purge interval of bucket in format of couchbase REST
+// API. Settings are false when bucket uses cluster auto compaction settings
+func autoCompactionJSON(settings url.Values) (interface{}, interface{}) {
+	if settings.Get("autoCompactionDefined") != "true" {
+		return false, nil
+	}
+
+	threshold := func(key string) interface{} {
+		if value, err := strconv.Atoi(settings.Get(key)); err == nil {
+			return value
+		}
+		return "undefined"
+	}
+	number := func(key string, value int) int {
+		if n, err := strconv.Atoi(settings.Get(key)); err == nil {
+			return n
+		}
+		return value
+	}
+
+	autoCompaction := map[string]interface{}{
+		"parallelDBAndViewCompaction": settings.Get("parallelDBAndViewCompaction") == "true",
+		"databaseFragmentationThreshold": map[string]interface{}{
+			"percentage": threshold("databaseFragmentationThreshold[percentage]"),
+			"size":       threshold("databaseFragmentationThreshold[size]"),
+		},
+		"viewFragmentationThreshold": map[string]interface{}{
+			"percentage": threshold("viewFragmentationThreshold[percentage]"),
+			"size":       threshold("viewFragmentationThreshold[size]"),
+		},
+		"magmaFragmentationPercentage": number("magmaFragmentationPercentage", 50),
+	}
+
+	if settings.Has("allowedTimePeriod[fromHour]") {
+		autoCompaction["allowedTimePeriod"] = map[string]interface{}{
+			"fromHour":     number("allowedTimePeriod[fromHour]", 0),
+			"fromMinute":   number("allowedTimePeriod[fromMinute]", 0),
+			"toHour":       number("allowedTimePeriod[toHour]", 0),
+			"toMinute":     number("allowedTimePeriod[toMinute]", 0),
+			"abortOutside": settings.Get("allowedTimePeriod[abortOutside]") == "true",
+		}
+	}
+
+	purgeInterval := 3.0
+	if value, err := strconv.ParseFloat(settings.Get("purgeInterval"), 64); err == nil {
+		purgeInterval = value
+	}
+
+	return autoCompaction, purgeInterval
+}
+
+// isAutoCompactionParam function checks if bucket REST API parameter belongs to auto compaction settings
+func isAutoCompactionParam(key string) bool {
+	switch key {
+	case "autoCompactionDefined", "parallelDBAndViewCompaction", "magmaFragmentationPercentage", "purgeInterval":
+		return true
+	}
+
+	return strings.HasPrefix(key, "databaseFragmentationThreshold[") ||
+		strings.HasPrefix(key, "viewFragmentationThreshold[") ||
+		strings.HasPrefix(key, "allowedTimePeriod[")
+}
+
+// validateBucket function validates bucket settings like cluster manager
+func validateBucket(settings url.Values) error {
+	errors := map[string]string{}
+
+	if quota, err := strconv.Atoi(settings.Get("ramQuotaMB")); settings.Has("ramQuotaMB") && (err != nil || quota < 100) {
+		errors["ramQuota"] = "RAM quota cannot be less than 100 MiB"
+	}
+
+	if quota, err := strconv.Atoi(settings.Get("ramQuotaMB")); settings.Get("storageBackend") == "magma" && err == nil && quota < 1024 {
+		errors["ramQuota"] = "RAM quota cannot be less than 1024 MiB for Magma bucket"
+	}
+
+	if settings.Get("storageBackend") != "magma" {
+		for _, key := range []string{"historyRetentionCollectionDefault", "historyRetentionBytes", "historyRetentionSeconds"} {
+			if settings.Has(key) {
+				errors[key] = "History retention can only used with Magma"
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{"errors": errors, "summaries": map[string]interface{}{}}}
+	}
+
+	return nil
+}
+
+// createBucket function creates bucket with default scope and collection. Caller must hold mutex
+func (s *fakeServer) createBucket(form url.Values) (interface{}, error) {
+	name := form.Get("name")
+
+	if _, ok := s.buckets[name]; ok {
+		return nil, &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{
+			"errors": map[string]string{"name": "Bucket with given name already exists"},
+		}}
+	}
+
+	if err := validateBucket(form); err != nil {
+		return nil, err
+	}
+
+	s.buckets[name] = &fakeServerBucket{
+		uuid:     strconv.Itoa(s.nextUID()),
+		settings: form,
+		scopes: map[string]map[string]fakeServerCollection{
+			"_default": {"_default": {}},
+		},
+	}
+
+	return &fakeServerStatus{status: http.StatusAccepted, body: ""}, nil
+}
+
+// bucket function reads, updates and drops bucket. Caller must hold mutex
+func (s *fakeServer) bucket(method, name string, form url.Values) (interface{}, error) {
+	bucket, ok := s.buckets[name]
+	if !ok {
+		return nil, &fakeServerError{status: http.StatusNotFound, body: "Requested resource not found.\r\n"}
+	}
+
+	switch method {
+	case http.MethodGet:
+		return bucket.settingsJSON(name), nil
+	case http.MethodPost:
+		settings := url.Values{}
+		for key, value := range bucket.settings {
+			// Auto compaction settings are replaced as whole like in cluster manager
+			if form.Has("autoCompactionDefined") && isAutoCompactionParam(key) {
+				continue
+			}
+			settings[key] = value
+		}
+		for key, value := range form {
+			settings[key] = value
+		}
+
+		if err := validateBucket(settings); err != nil {
+			return nil, err
+		}
+
+		bucket.settings = settings
+		return "", nil
+	case http.MethodDelete:
+		delete(s.buckets, name)
+		for id, idx := range s.indexes {
+			if idx.bucketName() == name {
+				delete(s.indexes, id)
+			}
+		}
+		return "", nil
+	}
+
+	return nil, &fakeServerError{status: http.StatusMethodNotAllowed, body: "Method Not Allowed"}
+}
+
+// collections function manages scopes and collections of bucket. Caller must hold mutex
+func (s *fakeServer) collections(method, bucketName string, names []string, form url.Values) (interface{}, error) {
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, &fakeServerError{status: http.StatusNotFound, body: "Requested resource not found.\r\n"}
+	}
+
+	scopeNotFound := &fakeServerError{status: http.StatusNotFound, body: map[string]interface{}{
+		"errors": map[string]string{"_": "Scope with this name is not found"},
+	}}
+
+	switch {
+	case method == http.MethodGet && len(names) == 0:
+		return bucket.manifestJSON(), nil
+	case method == http.MethodPost && len(names) == 0:
+		if _, ok := bucket.scopes[form.Get("name")]; ok {
+			return nil, &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{
+				"errors": map[string]string{"name": "Scope with this name already exists"},
+			}}
+		}
+		bucket.scopes[form.Get("name")] = map[string]fakeServerCollection{}
+	case method == http.MethodDelete && len(names) == 1:
+		if _, ok := bucket.scopes[names[0]]; !ok {
+			return nil, scopeNotFound
+		}
+		delete(bucket.scopes, names[0])
+	case method == http.MethodPost && len(names) == 2 && names[1] == "collections":
+		collections, ok := bucket.scopes[names[0]]
+		if !ok {
+			return nil, scopeNotFound
+		}
+		if _, ok := collections[form.Get("name")]; ok {
+			return nil, &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{
+				"errors": map[string]string{"name": "Collection with this name already exists"},
+			}}
+		}
+		if form.Has("history") && bucket.settings.Get("storageBackend") != "magma" {
+			return nil, &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{
+				"errors": map[string]string{"history": "Cannot enable history for non-Magma bucket"},
+			}}
+		}
+
+		maxTTL, _ := strconv.Atoi(form.Get("maxTTL"))
+		collections[form.Get("name")] = fakeServerCollection{maxTTL: maxTTL, history: form.Get("history") == "true"}
+	case method == http.MethodDelete && len(names) == 3 && names[1] == "collections":
+		collections, ok := bucket.scopes[names[0]]
+		if !ok {
+			return nil, scopeNotFound
+		}
+		if _, ok := collections[names[2]]; !ok {
+			return nil, &fakeServerError{status: http.StatusNotFound, body: map[string]interface{}{
+				"errors": map[string]string{"_": "Collection with this name is not found"},
+			}}
+		}
+		delete(collections, names[2])
+	default:
+		return nil, &fakeServerError{status: http.StatusNotFound, body: "Not found."}
+	}
+
+	bucket.manifest++
+	return map[string]string{"uid": strconv.FormatInt(int64(bucket.manifest), 16)}, nil
+}
+
+// manifestJSON function returns collections manifest of bucket in format of couchbase REST API
+func (b *fakeServerBucket) manifestJSON() map[string]interface{} {
+	var scopeNames []string
+	for name := range b.scopes {
+		scopeNames = append(scopeNames, name)
+	}
+	sort.Strings(scopeNames)
+
+	var scopes []interface{}
+	for i, scopeName := range scopeNames {
+		var collectionNames []string
+		for name := range b.scopes[scopeName] {
+			collectionNames = append(collectionNames, name)
+		}
+		sort.Strings(collectionNames)
+
+		collections := []interface{}{}
+		for j, collectionName := range collectionNames {
+			collection := b.scopes[scopeName][collectionName]
+			collections = append(collections, map[string]interface{}{
+				"name":    collectionName,
+				"uid":     strconv.FormatInt(int64(i*100+j), 16),
+				"maxTTL":  collection.maxTTL,
+				"history": collection.history,
+			})
+		}
+
+		scopes = append(scopes, map[string]interface{}{
+			"name":        scopeName,
+			"uid":         strconv.FormatInt(int64(i), 16),
+			"collections": collections,
+		})
+	}
+
+	return map[string]interface{}{
+		"uid":    strconv.FormatInt(int64(b.manifest), 16),
+		"scopes": scopes,
+	}
+}
+
+// parseRoles function parses roles from couchbase REST API form e.g. bucket_admin[bucket:scope:collection]
+func parseRoles(raw string) []jsonFakeServerRole {
+	roles := []jsonFakeServerRole{}
+
+	for _, rawRole := range strings.Split(raw, ",") {
+		if rawRole == "" {
+			continue
+		}
+
+		name, keyspace, _ := strings.Cut(strings.TrimSuffix(rawRole, "]"), "[")
+		role := jsonFakeServerRole{Role: name}
+
+		names := strings.Split(keyspace, ":")
+		if len(names) > 0 && names[0] != "*" {
+			role.Bucket = names[0]
+		}
+		if len(names) > 1 && names[1] != "*" {
+			role.Scope = names[1]
+		}
+		if len(names) > 2 && names[2] != "*" {
+			role.Collection = names[2]
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+// user function upserts, reads and drops local user. Caller must hold mutex
+func (s *fakeServer) user(method, name string, form url.Values) (interface{}, error) {
+	switch method {
+	case http.MethodPut:
+		var groups []string
+		for _, group := range strings.Split(form.Get("groups"), ",") {
+			if group == "" {
+				continue
+			}
+			if _, ok := s.groups[group]; !ok {
+				return nil, &fakeServerError{status: http.StatusBadRequest, body: map[string]interface{}{
+					"errors": map[string]string{"groups": fmt.Sprintf("Groups do not exist: %s", group)},
+				}}
+			}
+			groups = append(groups, group)
+		}
+
+		s.users[name] = fakeServerUser{name: form.Get("name"), roles: parseRoles(form.Get("roles")), groups: groups}
+		return "", nil
+	case http.MethodGet, http.MethodDelete:
+		user, ok := s.users[name]
+		if !ok {
+			return nil, &fakeServerError{status: http.StatusNotFound, body: "\"User was not found.\""}
+		}
+
+		if method == http.MethodDelete {
+			delete(s.users, name)
+			return "", nil
+		}
+
+		var roles []interface{}
+		for _, role := range user.roles {
+			roles = append(roles, map[string]interface{}{
+				"role":            role.Role,
+				"bucket_name":     role.Bucket,
+				"scope_name":      role.Scope,
+				"collection_name": role.Collection,
+				"origins":         []interface{}{map[string]string{"type": "user"}},
+			})
+		}
+
+		return map[string]interface{}{
+			"id":     name,
+			"domain": "local",
+			"name":   user.name,
+			"roles":  roles,
+			"groups": user.groups,
+		}, nil
+	}
+
+	return nil, &fakeServerError{status: http.StatusMethodNotAllowed, body: "Method Not Allowed"}
+}
+
+// group function upserts, reads and drops user group. Caller must hold mutex
+func (s *fakeServer) group(method, name string, form url.Values) (interface{}, error) {
+	switch method {
+	case http.MethodPut:
+		s.groups[name] = fakeServerGroup{
+			description: form.Get("description"),
+			roles:       parseRoles(form.Get("roles")),
+			ldap:        form.Get("ldap_group_ref"),
+		}
+		return "", nil
+	case http.MethodGet, http.MethodDelete:
+		group, ok := s.groups[name]
+		if !ok {
+			return nil, &fakeServerError{status: http.StatusNotFound, body: "\"Group was not found.\""}
+		}
+
+		if method == http.MethodDelete {
+			delete(s.groups, name)
+			return "", nil
+		}
+
+		return map[string]interface{}{
+			"id":             name,
+			"description":    group.description,
+			"roles":          group.roles,
+			"ldap_group_ref": group.ldap,
+		}, nil
+	}
+
+	return nil, &fakeServerError{status: http.StatusMethodNotAllowed, body: "Method Not Allowed"}
+}
+
+// alternateAddress function sets and deletes external alternate address of fake server node. Caller must hold mutex
+func (s *fakeServer) alternateAddress(method string, form url.Values) (interface{}, error) {
+	switch method {
+	case http.MethodPut:
+		address := alternateAddress{Hostname: form.Get("hostname")}
+		for name := range form {
+			if name == "hostname" {
+				continue
+			}
+
+			port, err := strconv.Atoi(form.Get(name))
+			if err != nil {
+				return nil, &fakeServerError{status: http.StatusBadRequest, body: fmt.Sprintf("[\"Invalid port %s\"]", name)}
+			}
+			if address.Ports == nil {
+				address.Ports = map[string]int{}
+			}
+			address.Ports[name] = port
+		}
+
+		s.alternateAddresses[fakeServerHost] = address
+		return "", nil
+	case http.MethodDelete:
+		delete(s.alternateAddresses, fakeServerHost)
+		return "", nil
+	}
+
+	return nil, &fakeServerError{status: http.StatusMethodNotAllowed, body: "Method Not Allowed"}
+}
+
+// indexStatus function returns indexer status of query indexes. Caller must hold mutex
+func (s *fakeServer) indexStatus() queryIndexStatusList {
+	list := queryIndexStatusList{Indexes: []queryIndexStatus{}}
+
+	for _, idx := range s.indexes {
+		status := "Ready"
+		if idx.State == getDeferredState(true) {
+			status = "Created"
+		}
+
+		list.Indexes = append(list.Indexes, queryIndexStatus{
+			ID:           json.Number(idx.ID),
+			Name:         idx.Name,
+			Hosts:        []string{net.JoinHostPort(fakeServerHost, strconv.Itoa(s.httpPort))},
+			Status:       status,
+			NumReplica:   idx.with.NumReplica,
+			NumPartition: idx.with.NumPartition,
+			Progress:     100,
+		})
+	}
+
+	return list
+}
+
+// queryError function returns query service error response with error code
+func queryError(code int, message string) error {
+	return &fakeServerError{status: http.StatusInternalServerError, body: map[string]interface{}{
+		"requestID": "fake",
+		"errors":    []interface{}{map[string]interface{}{"code": code, "msg": message}},
+		"status":    "errors",
+	}}
+}
+
+// queryResults function returns successful query service response with result rows
+func queryResults(results []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"requestID": "fake",
+		"results":   results,
+		"status":    "success",
+		"metrics": map[string]interface{}{
+			"elapsedTime":   "1ms",
+			"executionTime": "1ms",
+			"resultCount":   len(results),
+			"resultSize":    0,
+		},
+	}
+}
+
+// splitExpressions function splits index keys by commas which are not nested in parentheses or quotes
+func splitExpressions(raw string) []string {
+	var (
+		expressions []string
+		depth       int
+		quote       rune
+		start       int
+	)
+
+	for i, r := range raw {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '`' || r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			expressions = append(expressions, strings.TrimSpace(raw[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(expressions, strings.TrimSpace(raw[start:]))
+}
+
+// closingParenthesis function returns position of parenthesis which closes parenthesis at start position
+func closingParenthesis(raw string, start int) int {
+	depth := 0
+	for i := start; i < len(raw); i++ {
+		switch raw[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// query function runs query request. Gocb prepares statements with auto execute and later sends only name
+// of prepared statement so fake server keeps prepared statements. Caller must hold mutex
+func (s *fakeServer) query(body []byte) (interface{}, error) {
+	var request struct {
+		Statement string        `json:"statement"`
+		Prepared  string        `json:"prepared"`
+		Args      []interface{} `json:"args"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, queryError(1065, err.Error())
+	}
+
+	if request.Prepared != "" {
+		statement, ok := s.prepared[request.Prepared]
+		if !ok {
+			return nil, queryError(4040, fmt.Sprintf("No such prepared statement: %s", request.Prepared))
+		}
+		return s.execute(statement, request.Args)
+	}
+
+	statement, prepare := strings.CutPrefix(strings.TrimSpace(request.Statement), "PREPARE ")
+	if !prepare {
+		return s.execute(statement, request.Args)
+	}
+
+	name := strconv.Itoa(s.nextUID())
+	s.prepared[name] = statement
+
+	response, err := s.execute(statement, request.Args)
+	if results, ok := response.(map[string]interface{}); ok {
+		results["prepared"] = name
+	}
+
+	return response, err
+}
+
+// execute function runs query index statements sent by provider and gocb. Caller must hold mutex
+func (s *fakeServer) execute(statement string, args []interface{}) (interface{}, error) {
+	if match := fakeQuerySelectIndex.FindStringSubmatch(statement); match != nil {
+		return s.selectIndexes(match[1], args)
+	}
+
+	if match := fakeQueryCreatePrimaryIndex.FindStringSubmatch(statement); match != nil {
+		return s.createIndex(queryIndex{Name: match[1], IsPrimary: true}, match[2], match[3])
+	}
+
+	if strings.HasPrefix(statement, "CREATE INDEX `") {
+		return s.createSecondaryIndex(statement)
+	}
+
+	if match := fakeQueryAlterIndex.FindStringSubmatch(statement); match != nil {
+		idx, err := s.findIndex(match[1], match[2])
+		if err != nil {
+			return nil, err
+		}
+
+		var with struct {
+			NumReplica int `json:"num_replica"`
+		}
+		if err := json.Unmarshal([]byte(match[3]), &with); err != nil {
+			return nil, queryError(3000, err.Error())
+		}
+		if with.NumReplica > 0 {
+			return nil, queryError(5000, "GSI AlterIndex() - cause: Fails to alter index. There are not enough indexer nodes")
+		}
+
+		idx.with.NumReplica = with.NumReplica
+		return queryResults(nil), nil
+	}
+
+	if match := fakeQueryBuildIndex.FindStringSubmatch(statement); match != nil {
+		for _, name := range splitExpressions(match[2]) {
+			idx, err := s.findIndex(strings.Trim(name, "`"), match[1])
+			if err != nil {
+				return nil, err
+			}
+			idx.State = getDeferredState(false)
+		}
+		return queryResults(nil), nil
+	}
+
+	var name, keyspace string
+	if match := fakeQueryDropIndex.FindStringSubmatch(statement); match != nil {
+		name, keyspace = match[1], match[2]
+	} else if match := fakeQueryDropBucketIndex.FindStringSubmatch(statement); match != nil {
+		name, keyspace = match[2], match[1]
+	} else if match := fakeQueryDropPrimaryIndex.FindStringSubmatch(statement); match != nil {
+		name, keyspace = "#primary", match[1]
+	} else {
+		return nil, queryError(3000, fmt.Sprintf("syntax error - statement isn't supported by fake server: %s", statement))
+	}
+
+	idx, err := s.findIndex(name, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.indexes, idx.ID)
+
+	return queryResults(nil), nil
+}
+
+// createSecondaryIndex function parses CREATE INDEX statement created by provider. Caller must hold mutex
+func (s *fakeServer) createSecondaryIndex(statement string) (interface{}, error) {
+	rest := strings.TrimPrefix(statement, "CREATE INDEX `")
+
+	name, rest, _ := strings.Cut(rest, "` ON ")
+	start := strings.Index(rest, "(")
+	end := closingParenthesis(rest, start)
+	if start < 0 || end < 0 {
+		return nil, queryError(3000, "syntax error - index keys are missing")
+	}
+
+	idx := queryIndex{Name: name, IndexKey: splitExpressions(rest[start+1 : end])}
+	keyspace := rest[:start]
+	rest = strings.TrimSpace(rest[end+1:])
+
+	if strings.HasPrefix(rest, "PARTITION BY HASH(") {
+		end := closingParenthesis(rest, len("PARTITION BY HASH"))
+		idx.Partition = rest[len("PARTITION BY ") : end+1]
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	with := strings.LastIndex(rest, "WITH {")
+	if with < 0 {
+		return nil, queryError(3000, "syntax error - WITH clause is missing")
+	}
+	if strings.HasPrefix(rest, "WHERE ") {
+		idx.Condition = strings.TrimSpace(rest[len("WHERE "):with])
+	}
+
+	return s.createIndex(idx, keyspace, rest[with+len("WITH "):])
+}
+
+// createIndex function stores query index with settings from WITH clause. Caller must hold mutex
+func (s *fakeServer) createIndex(idx queryIndex, keyspace string, rawWith string) (interface{}, error) {
+	var with queryIndexWith
+	if err := json.Unmarshal([]byte(rawWith), &with); err != nil {
+		return nil, queryError(3000, err.Error())
+	}
+
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, queryError(12003, fmt.Sprintf("Keyspace not found in CB datastore: default:%s", strings.ReplaceAll(keyspace, "`", "")))
+	}
+	if !isDefaultCollection(scopeName, collectionName) {
+		if _, ok := bucket.scopes[scopeName][collectionName]; !ok {
+			return nil, queryError(12003, fmt.Sprintf("Keyspace not found in CB datastore: default:%s", strings.ReplaceAll(keyspace, "`", "")))
+		}
+	}
+
+	if existing, _ := s.findIndex(idx.Name, keyspace); existing != nil {
+		return nil, queryError(4300, fmt.Sprintf("The index %s already exists.", idx.Name))
+	}
+
+	if with.NumReplica > 0 {
+		return nil, queryError(5000, fmt.Sprintf("GSI CreateIndex() - cause: Fails to create index. There are not enough indexer nodes to create index with replica count of %d.", with.NumReplica))
+	}
+
+	idx.ID = strconv.Itoa(s.nextUID())
+	idx.Using = "gsi"
+	idx.NamespaceID = "default"
+	idx.DatastoreID = "http://" + net.JoinHostPort(fakeServerHost, strconv.Itoa(s.httpPort))
+	idx.State = getDeferredState(with.DeferBuild)
+	idx.KeyspaceID = bucketName
+	if !isDefaultCollection(scopeName, collectionName) {
+		idx.BucketID = bucketName
+		idx.ScopeID = scopeName
+		idx.KeyspaceID = collectionName
+	}
+
+	s.indexes[idx.ID] = &fakeServerIndex{queryIndex: idx, with: with}
+	return queryResults(nil), nil
+}
+
+// findIndex function returns query index by name and keyspace. Caller must hold mutex
+func (s *fakeServer) findIndex(name, keyspace string) (*fakeServerIndex, error) {
+	bucketName, scopeName, collectionName := fakeKeyspace(keyspace)
+	keyspace = getKeyspace(bucketName, scopeName, collectionName)
+
+	for _, idx := range s.indexes {
+		if idx.keyspace() != keyspace {
+			continue
+		}
+		if idx.Name == name || (name == "#primary" && idx.IsPrimary) {
+			return idx, nil
+		}
+	}
+
+	return nil, queryError(12004, fmt.Sprintf("GSI index %s not found.", name))
+}
+
+// selectIndexes function returns query indexes from system:indexes which match conditions of provider select.
+// Conditions are joined with AND and compare index attribute with positional parameter or check missing bucket
+func (s *fakeServer) selectIndexes(where string, args []interface{}) (interface{}, error) {
+	var ids []string
+	for id := range s.indexes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	results := []interface{}{}
+	for _, id := range ids {
+		idx := s.indexes[id]
+
+		attributes := map[string]string{
+			"id":          idx.ID,
+			"name":        idx.Name,
+			"bucket_id":   idx.BucketID,
+			"scope_id":    idx.ScopeID,
+			"keyspace_id": idx.KeyspaceID,
+			"`using`":     idx.Using,
+		}
+
+		match := true
+		arg := 0
+		for _, condition := range strings.Split(where, " AND ") {
+			switch {
+			case strings.HasSuffix(condition, " IS MISSING"):
+				match = match && attributes[strings.TrimSuffix(condition, " IS MISSING")] == ""
+			case strings.HasSuffix(condition, "=?"):
+				if arg >= len(args) {
+					return nil, queryError(3000, "missing positional parameter")
+				}
+				match = match && attributes[strings.TrimSuffix(condition, "=?")] == fmt.Sprint(args[arg])
+				arg++
+			default:
+				name, value, _ := strings.Cut(condition, "=")
+				match = match && attributes[name] == strings.Trim(value, "\"")
+			}
+		}
+
+		if match {
+			results = append(results, idx.queryIndex)
+		}
+	}
+
+	return queryResults(results), nil
+}
+
+// testFakeServerPreCheck function skips test when terraform CLI which runs provider test steps isn't installed
+func testFakeServerPreCheck(t *testing.T) {
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" {
+		return
+	}
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform CLI isn't installed, set TF_ACC_TERRAFORM_PATH to run provider test steps against fake server")
+	}
+}
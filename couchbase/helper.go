@@ -3,7 +3,9 @@ package couchbase
 import (
 	"fmt"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // diagForValueSet function which create custom diagnostic message with severity error
@@ -16,3 +18,33 @@ func diagForValueSet(key string, value interface{}, err error) *diag.Diagnostic
 		Detail: fmt.Sprintf("error details: %s\n", err),
 	}
 }
+
+// requiresReplacement function checks if planned change of any attribute forces replacement of resource
+func requiresReplacement(d *schema.ResourceDiff, attributes map[string]*schema.Schema) bool {
+	for key, attribute := range attributes {
+		if attribute.ForceNew && d.HasChange(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// configAttribute function returns attribute value from raw resource configuration. Null value is returned when
+// configuration isn't available
+func configAttribute(config cty.Value, key string) cty.Value {
+	if config.IsNull() || !config.IsKnown() || !config.Type().IsObjectType() || !config.Type().HasAttribute(key) {
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+	return config.GetAttr(key)
+}
+
+// configuredAttributes function returns attributes which are set in raw resource configuration
+func configuredAttributes(config cty.Value, keys ...string) []string {
+	var configured []string
+	for _, key := range keys {
+		if !configAttribute(config, key).IsNull() {
+			configured = append(configured, key)
+		}
+	}
+	return configured
+}
@@ -0,0 +1,376 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// indexServiceName is name of index service in couchbase node services
+const indexServiceName = "index"
+
+// settledIndexStatus contains indexer status values of index replicas which are not moving or building
+var settledIndexStatus = []string{"Ready", "Created"}
+
+// queryIndexStatus custom structure for index status returned by indexer. Every index replica and partition
+// placement has own status with the same index ID
+type queryIndexStatus struct {
+	ID           json.Number `json:"id"`
+	Name         string      `json:"index"`
+	Hosts        []string    `json:"hosts"`
+	Status       string      `json:"status"`
+	NumReplica   int         `json:"numReplica"`
+	NumPartition int         `json:"numPartition"`
+	ReplicaID    int         `json:"replicaId"`
+	Progress     float64     `json:"progress"`
+}
+
+// queryIndexStatusKeys custom structure with resource keys of query index attributes reported by indexer
+type queryIndexStatusKeys struct {
+	NumReplica   string
+	NumPartition string
+	Status       string
+	Hosts        string
+	Progress     string
+}
+
+// isSettled function checks if index replica is not moving or building
+func (s *queryIndexStatus) isSettled() bool {
+	for _, status := range settledIndexStatus {
+		if s.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// queryIndexStatusList custom structure for indexer /indexStatus response
+type queryIndexStatusList struct {
+	Indexes []queryIndexStatus `json:"indexes"`
+}
+
+// clusterNode custom structure for node information returned by /pools/default
+type clusterNode struct {
+	Hostname string   `json:"hostname"`
+	Services []string `json:"services"`
+}
+
+// clusterNodeList custom structure for /pools/default response
+type clusterNodeList struct {
+	Nodes []clusterNode `json:"nodes"`
+}
+
+// getIndexStatusList function returns indexer status of all indexes in cluster. Indexer doesn't filter status by
+// index, so response is shared by query index reads for indexStatusCacheTTL unless fresh status is required
+func (cc *Connection) getIndexStatusList(c context.Context, fresh bool) (*queryIndexStatusList, error) {
+	cc.indexStatusMutex.Lock()
+	defer cc.indexStatusMutex.Unlock()
+
+	if !fresh && cc.indexStatusList != nil && time.Since(cc.indexStatusFetched) < time.Duration(indexStatusCacheTTL)*time.Second {
+		return cc.indexStatusList, nil
+	}
+
+	var statusList queryIndexStatusList
+	if err := cc.management().get(c, managementServiceCluster, "/indexStatus", &statusList); err != nil {
+		return nil, err
+	}
+
+	cc.indexStatusList = &statusList
+	cc.indexStatusFetched = time.Now()
+
+	return cc.indexStatusList, nil
+}
+
+// invalidateIndexStatus function drops cached indexer status after query index state was changed
+func (cc *Connection) invalidateIndexStatus() {
+	cc.indexStatusMutex.Lock()
+	defer cc.indexStatusMutex.Unlock()
+
+	cc.indexStatusList = nil
+}
+
+// getQueryIndexStatus function returns all indexer status entries of query index. Cached indexer status is used
+// when cached is true and it contains the index, otherwise fresh status is downloaded
+func (cc *Connection) getQueryIndexStatus(c context.Context, indexID string, cached bool) ([]queryIndexStatus, error) {
+	statusList, err := cc.getIndexStatusList(c, !cached)
+	if err != nil {
+		return nil, err
+	}
+
+	status := filterQueryIndexStatus(statusList, indexID)
+	if cached && len(status) == 0 {
+		// Index created after status was cached isn't in cached status
+		if statusList, err = cc.getIndexStatusList(c, true); err != nil {
+			return nil, err
+		}
+		status = filterQueryIndexStatus(statusList, indexID)
+	}
+
+	return status, nil
+}
+
+// filterQueryIndexStatus function returns indexer status entries of one query index
+func filterQueryIndexStatus(statusList *queryIndexStatusList, indexID string) []queryIndexStatus {
+	var status []queryIndexStatus
+	for _, index := range statusList.Indexes {
+		if index.ID.String() == indexID {
+			status = append(status, index)
+		}
+	}
+
+	return status
+}
+
+// getQueryIndexHosts function returns sorted list of nodes where query index and its replicas are placed
+func getQueryIndexHosts(status []queryIndexStatus) []string {
+	unique := map[string]bool{}
+	hosts := []string{}
+	for _, index := range status {
+		for _, host := range index.Hosts {
+			if !unique[host] {
+				unique[host] = true
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// getQueryIndexState function returns status and progress of query index. When replicas differ, status
+// of first replica which is not settled and the lowest progress are returned
+func getQueryIndexState(status []queryIndexStatus) (string, float64) {
+	state := status[0].Status
+	progress := status[0].Progress
+
+	for _, index := range status {
+		if !index.isSettled() {
+			state = index.Status
+			break
+		}
+	}
+
+	for _, index := range status {
+		if index.Progress < progress {
+			progress = index.Progress
+		}
+	}
+
+	return state, progress
+}
+
+// setQueryIndexStatus function sets query index attributes reported by indexer to resource data. Placement
+// of index is set only to hosts because indexer uses only number of replicas + 1 of configured nodes
+func (cc *Connection) setQueryIndexStatus(c context.Context, d *schema.ResourceData, indexID string, keys queryIndexStatusKeys) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	status, err := cc.getQueryIndexStatus(c, indexID, true)
+	if err != nil {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("cannot download couchbase index status for index id: %s \n", indexID),
+			Detail:   fmt.Sprintf("error details: %s\n", err),
+		})
+	}
+
+	if len(status) == 0 {
+		return diags
+	}
+
+	hosts := getQueryIndexHosts(status)
+	state, progress := getQueryIndexState(status)
+
+	if err := d.Set(keys.Hosts, hosts); err != nil {
+		diags = append(diags, *diagForValueSet(keys.Hosts, hosts, err))
+	}
+
+	if err := d.Set(keys.NumReplica, status[0].NumReplica); err != nil {
+		diags = append(diags, *diagForValueSet(keys.NumReplica, status[0].NumReplica, err))
+	}
+
+	if err := d.Set(keys.NumPartition, status[0].NumPartition); err != nil {
+		diags = append(diags, *diagForValueSet(keys.NumPartition, status[0].NumPartition, err))
+	}
+
+	if err := d.Set(keys.Status, state); err != nil {
+		diags = append(diags, *diagForValueSet(keys.Status, state, err))
+	}
+
+	if err := d.Set(keys.Progress, progress); err != nil {
+		diags = append(diags, *diagForValueSet(keys.Progress, progress, err))
+	}
+
+	return diags
+}
+
+// customizeQueryIndexNodes function forces replacement of existing query index when placement nodes change
+// and index or its replicas are placed on node which isn't in new nodes. Index which is already placed
+// on new nodes (e.g. imported index or index with more nodes than replicas) only records new nodes
+func customizeQueryIndexNodes(d *schema.ResourceDiff, keyNodes, keyHosts string) (bool, error) {
+	if d.Id() == "" || !d.HasChange(keyNodes) {
+		return false, nil
+	}
+
+	hosts, _ := d.Get(keyHosts).([]interface{})
+	nodes, _ := d.Get(keyNodes).(*schema.Set)
+	placed := len(hosts) > 0 && nodes != nil && d.NewValueKnown(keyNodes)
+	for _, host := range hosts {
+		if !placed {
+			break
+		}
+		placed = nodes.Contains(host)
+	}
+
+	if placed {
+		return false, nil
+	}
+
+	return true, d.ForceNew(keyNodes)
+}
+
+// getQueryIndexReplicasToDrop function returns IDs of query index replicas which are dropped when number of replicas
+// decreases. Replicas placed on nodes which are not in configured nodes are dropped first, then replicas with
+// the highest replica ID
+func getQueryIndexReplicasToDrop(status []queryIndexStatus, numReplica int, nodes []string) []int {
+	placed := map[string]bool{}
+	for _, node := range nodes {
+		placed[node] = true
+	}
+
+	outside := map[int]bool{}
+	replicaIDs := []int{}
+	for _, index := range status {
+		if _, ok := outside[index.ReplicaID]; !ok {
+			replicaIDs = append(replicaIDs, index.ReplicaID)
+			outside[index.ReplicaID] = false
+		}
+		for _, host := range index.Hosts {
+			if len(nodes) > 0 && !placed[host] {
+				outside[index.ReplicaID] = true
+			}
+		}
+	}
+
+	sort.SliceStable(replicaIDs, func(i, j int) bool {
+		if outside[replicaIDs[i]] != outside[replicaIDs[j]] {
+			return outside[replicaIDs[i]]
+		}
+		return replicaIDs[i] > replicaIDs[j]
+	})
+
+	if len(replicaIDs) <= numReplica+1 {
+		return nil
+	}
+	return replicaIDs[:len(replicaIDs)-numReplica-1]
+}
+
+// alterQueryIndexReplicas function changes number of query index replicas in place. Replicas are added with
+// replica_count action on configured nodes. Removed replicas are dropped one by one with drop_replica action,
+// so replicas placed outside of configured nodes are dropped first
+func (cc *Connection) alterQueryIndexReplicas(c context.Context, qm queryIndexManager, indexID, indexName, keyspace string, numReplica int, nodes []string, timeout time.Duration) error {
+	status, err := cc.getQueryIndexStatus(c, indexID, false)
+	if err != nil {
+		return err
+	}
+
+	drop := getQueryIndexReplicasToDrop(status, numReplica, nodes)
+	if len(drop) == 0 {
+		return cc.queryIndexDDL(c, timeout, func() error {
+			return qm.alterQueryIndexReplicaCount(c, indexName, keyspace, numReplica, nodes, timeout)
+		})
+	}
+
+	for _, replicaID := range drop {
+		if err := cc.queryIndexDDL(c, timeout, func() error {
+			return qm.dropQueryIndexReplica(c, indexName, keyspace, replicaID, timeout)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitQueryIndexReplicas function waits until indexer has expected number of query index replicas
+// and all replicas are settled
+func (cc *Connection) waitQueryIndexReplicas(c context.Context, indexID string, numReplica int, timeout time.Duration) error {
+	return waitFor(c, logSubsystemIndex, "change query index replicas", timeout, func() *retry.RetryError {
+
+		status, err := cc.getQueryIndexStatus(c, indexID, false)
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+
+		replicas := map[int]bool{}
+		for _, index := range status {
+			if !index.isSettled() {
+				return retry.RetryableError(fmt.Errorf("query index: %s replica: %d is in status: %s", index.Name, index.ReplicaID, index.Status))
+			}
+			replicas[index.ReplicaID] = true
+		}
+
+		if len(replicas) != numReplica+1 {
+			return retry.RetryableError(fmt.Errorf("query index id: %s has %d instances, expected: %d", indexID, len(replicas), numReplica+1))
+		}
+
+		return nil
+	})
+}
+
+// getIndexNodes function returns hostnames of cluster nodes with index service
+func (cc *Connection) getIndexNodes(c context.Context) ([]string, error) {
+	var (
+		nodeList clusterNodeList
+		nodes    []string
+	)
+
+	if err := cc.management().get(c, managementServiceCluster, "/pools/default", &nodeList); err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodeList.Nodes {
+		for _, service := range node.Services {
+			if service == indexServiceName {
+				nodes = append(nodes, node.Hostname)
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// validateQueryIndexNodes function checks that query index placement nodes exist and run index service
+func (cc *Connection) validateQueryIndexNodes(c context.Context, nodes []string) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	indexNodes, err := cc.getIndexNodes(c)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		found := false
+		for _, indexNode := range indexNodes {
+			if node == indexNode {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("node: %s is not couchbase index node, available index nodes: %s", node, strings.Join(indexNodes, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// Provider log subsystems. Log level of subsystem can be changed with environment variable
+	// TF_LOG_PROVIDER_COUCHBASE_<SUBSYSTEM> e.g. TF_LOG_PROVIDER_COUCHBASE_INDEX=TRACE
+	logSubsystemConnection = "connection"
+	logSubsystemBucket     = "bucket"
+	logSubsystemIndex      = "index"
+	logSubsystemSecurity   = "security"
+	logSubsystemCollection = "collection"
+	logSubsystemRetry      = "retry"
+	logSubsystemSDK        = "gocb"
+
+	// logLevelEnv is prefix of environment variables with log level of provider subsystems
+	logLevelEnv = "TF_LOG_PROVIDER_COUCHBASE"
+)
+
+// logMaskedFields contains log field keys which values are always masked
+var logMaskedFields = []string{
+	keySecurityUserPassword,
+	providerClientKey,
+	"authorization",
+}
+
+// newLogContext function returns context with provider log subsystem. Values of credential fields and
+// secrets in messages are masked
+func newLogContext(c context.Context, subsystem string, secrets ...string) context.Context {
+	c = tflog.NewSubsystem(c, subsystem, tflog.WithLevelFromEnv(logLevelEnv, strings.ToUpper(subsystem)))
+	c = tflog.SubsystemMaskFieldValuesWithFieldKeys(c, subsystem, logMaskedFields...)
+
+	for _, secret := range secrets {
+		if secret != "" {
+			c = tflog.SubsystemMaskLogStrings(c, subsystem, secret)
+		}
+	}
+
+	return c
+}
+
+// logContext function returns context with provider log subsystem and masked connection credentials
+func (cc *Connection) logContext(c context.Context, subsystem string) context.Context {
+	return newLogContext(c, subsystem, cc.ClusterOptions.Password)
+}
+
+// sdkLogger struct forwards gocb internal logs to terraform logs
+type sdkLogger struct {
+	c context.Context
+}
+
+// newSDKLogger function creates gocb logger which writes to gocb log subsystem of provider context
+func newSDKLogger(c context.Context, secrets ...string) *sdkLogger {
+	return &sdkLogger{c: newLogContext(c, logSubsystemSDK, secrets...)}
+}
+
+// Log function writes gocb log message with terraform log level matching gocb log level
+func (l *sdkLogger) Log(level gocb.LogLevel, _ int, format string, v ...interface{}) error {
+	message := fmt.Sprintf(format, v...)
+
+	switch level {
+	case gocb.LogError:
+		tflog.SubsystemError(l.c, logSubsystemSDK, message)
+	case gocb.LogWarn:
+		tflog.SubsystemWarn(l.c, logSubsystemSDK, message)
+	case gocb.LogInfo:
+		tflog.SubsystemInfo(l.c, logSubsystemSDK, message)
+	case gocb.LogDebug:
+		tflog.SubsystemDebug(l.c, logSubsystemSDK, message)
+	default:
+		tflog.SubsystemTrace(l.c, logSubsystemSDK, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package couchbase
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// TestLogContext function verify
+// - credential fields and secrets in messages are masked
+// - gocb logs are forwarded to gocb log subsystem
+func TestLogContext(t *testing.T) {
+	var output bytes.Buffer
+	c := tflogtest.RootLogger(context.Background(), &output)
+
+	c = newLogContext(c, logSubsystemSecurity, "secret")
+	tflog.SubsystemDebug(c, logSubsystemSecurity, "user secret created", map[string]interface{}{keySecurityUserPassword: "password"})
+
+	logger := newSDKLogger(c, "secret")
+	if err := logger.Log(gocb.LogWarn, 0, "connection %s failed", "secret"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of log entries: %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if strings.Contains(entry["@message"].(string), "secret") {
+			t.Fatalf("secret isn't masked: %v", entry)
+		}
+	}
+	if entries[0][keySecurityUserPassword] != "***" {
+		t.Fatalf("password field isn't masked: %v", entries[0])
+	}
+	if entries[1]["@module"] != "provider."+logSubsystemSDK || entries[1]["@level"] != "warn" {
+		t.Fatalf("unexpected gocb log entry: %v", entries[1])
+	}
+}
+
+// TestRetryLogContext function verify that retry attempts are logged to retry log subsystem
+func TestRetryLogContext(t *testing.T) {
+	var output bytes.Buffer
+	c := tflogtest.RootLogger(context.Background(), &output)
+
+	cc, _ := newFakeConnection()
+	cc.Retry = RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+
+	attempts := 0
+	if err := cc.retryOperation(c, func() error {
+		attempts++
+		if attempts == 1 {
+			return gocb.ErrTemporaryFailure
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of log entries: %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if entry["@module"] != "provider."+logSubsystemRetry || entry["@level"] != "debug" {
+			t.Fatalf("unexpected retry log entry: %v", entry)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package couchbase
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// managementService custom type for couchbase services which provide REST API
+type managementService int
+
+const (
+	managementServiceCluster managementService = iota
+	managementServiceQuery
+	managementServiceSearch
+	managementServiceEventing
+	managementServiceIndex
+)
+
+// managementServiceKeys contains http and https port names of couchbase services in /pools/default/nodeServices.
+// Cluster management port is taken from provider client port. You can read more about ports here:
+// https://docs.couchbase.com/server/current/install/install-ports.html
+var managementServiceKeys = map[managementService][2]string{
+	managementServiceCluster:  {"mgmt", "mgmtSSL"},
+	managementServiceQuery:    {"n1ql", "n1qlSSL"},
+	managementServiceSearch:   {"fts", "ftsSSL"},
+	managementServiceEventing: {"eventingAdminPort", "eventingSSL"},
+	managementServiceIndex:    {"indexHttp", "indexHttps"},
+}
+
+var (
+	// ErrManagementBadRequest is returned when couchbase REST API rejects request parameters
+	ErrManagementBadRequest = errors.New("couchbase management request is invalid")
+	// ErrManagementUnauthorized is returned when couchbase REST API rejects credentials
+	ErrManagementUnauthorized = errors.New("couchbase management authentication failed")
+	// ErrManagementForbidden is returned when user doesn't have permission for couchbase REST API request
+	ErrManagementForbidden = errors.New("couchbase management permission denied")
+	// ErrManagementNotFound is returned when couchbase REST API resource doesn't exist
+	ErrManagementNotFound = errors.New("couchbase management resource not found")
+	// ErrManagementUnavailable is returned when couchbase service is temporarily unavailable
+	ErrManagementUnavailable = errors.New("couchbase management service is unavailable")
+)
+
+// ErrManagementRequest custom error structure for couchbase REST API responses with non 2xx status code
+type ErrManagementRequest struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+// Error function returns custom message for failed couchbase REST API request
+func (e *ErrManagementRequest) Error() string {
+	return fmt.Sprintf("couchbase request: %s %s failed with status: %d response: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Unwrap function maps status code of failed request to management error so it can be checked with errors.Is
+func (e *ErrManagementRequest) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return ErrManagementBadRequest
+	case http.StatusUnauthorized:
+		return ErrManagementUnauthorized
+	case http.StatusForbidden:
+		return ErrManagementForbidden
+	case http.StatusNotFound:
+		return ErrManagementNotFound
+	case http.StatusServiceUnavailable:
+		return ErrManagementUnavailable
+	}
+	return nil
+}
+
+// isRetryable function checks if failed request can be repeated
+func (e *ErrManagementRequest) isRetryable() bool {
+	if e.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+
+	body := strings.ToLower(e.Body)
+	for _, message := range transientErrorMessages {
+		if strings.Contains(body, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// managementEndpoint custom structure for host and port of couchbase service
+type managementEndpoint struct {
+	host string
+	port int
+}
+
+// managementClient struct contains shared http client for couchbase REST API. Currently gocb v2 doesn't support
+// some operations so we must use http/https and client-to-node ports for them. Requests are sent to seed nodes
+// in order until one of them responds.
+type managementClient struct {
+	client      *http.Client
+	scheme      string
+	addresses   []string
+	clusterPort int
+	network     string
+	policy      RetryPolicy
+	username    string
+	password    string
+	tracer      trace.Tracer
+
+	// certificateAuth is true when client certificate is used instead of username and password
+	certificateAuth bool
+}
+
+// newManagementClient function creates couchbase REST API client from connection parameters
+func newManagementClient(cc *Connection) *managementClient {
+	scheme := "http"
+	if cc.Scheme == "couchbases" || cc.ClusterOptions.SecurityConfig.TLSRootCAs != nil {
+		scheme = "https"
+	}
+
+	tlsConfig := &tls.Config{
+		// nolint:gosec
+		InsecureSkipVerify: cc.ClusterOptions.SecurityConfig.TLSSkipVerify,
+		RootCAs:            cc.ClusterOptions.SecurityConfig.TLSRootCAs,
+	}
+
+	mc := &managementClient{
+		client: &http.Client{
+			Timeout: cc.ClusterOptions.TimeoutsConfig.ManagementTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		scheme:      scheme,
+		addresses:   cc.seedHosts(),
+		clusterPort: cc.ClientPort,
+		network:     cc.Network,
+		policy:      cc.Retry,
+		username:    cc.ClusterOptions.Username,
+		password:    cc.ClusterOptions.Password,
+		tracer:      cc.tracer(),
+	}
+
+	// Client certificate replaces basic authentication
+	if authenticator, ok := cc.ClusterOptions.Authenticator.(gocb.CertificateAuthenticator); ok && authenticator.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*authenticator.ClientCertificate}
+		mc.certificateAuth = true
+	}
+
+	return mc
+}
+
+// get function sends GET request to couchbase service and decodes json response to result
+func (mc *managementClient) get(c context.Context, service managementService, path string, result interface{}) error {
+	return mc.request(c, http.MethodGet, service, path, nil, result)
+}
+
+// post function sends form data to couchbase service and decodes json response to result when result isn't nil
+func (mc *managementClient) post(c context.Context, service managementService, path string, form url.Values, result interface{}) error {
+	return mc.request(c, http.MethodPost, service, path, form, result)
+}
+
+// request function sends request to couchbase service and repeats it while service is temporarily unavailable
+func (mc *managementClient) request(c context.Context, method string, service managementService, path string, form url.Values, result interface{}) error {
+	endpoints, err := mc.endpoints(c, service)
+	if err != nil {
+		return err
+	}
+
+	return mc.retry(c, func() error {
+		return mc.send(c, method, endpoints, path, form, result)
+	})
+}
+
+// requestNode function sends request to cluster management API of one couchbase node. It is used for
+// node specific settings which must be changed on node itself
+func (mc *managementClient) requestNode(c context.Context, method string, host string, path string, form url.Values, result interface{}) error {
+	endpoints := []managementEndpoint{{host: host, port: mc.clusterPort}}
+
+	return mc.retry(c, func() error {
+		return mc.send(c, method, endpoints, path, form, result)
+	})
+}
+
+// retry function repeats request with provider retry policy while couchbase service is temporarily unavailable
+func (mc *managementClient) retry(c context.Context, request func() error) error {
+	return mc.policy.do(newLogContext(c, logSubsystemRetry, mc.password), request)
+}
+
+// clusterEndpoints function returns cluster management endpoints of seed nodes
+func (mc *managementClient) clusterEndpoints() []managementEndpoint {
+	endpoints := make([]managementEndpoint, 0, len(mc.addresses))
+	for _, address := range mc.addresses {
+		endpoints = append(endpoints, managementEndpoint{host: address, port: mc.clusterPort})
+	}
+
+	return endpoints
+}
+
+// endpoints function returns endpoints of nodes which run couchbase service. Cluster management requests
+// are sent to seed nodes, other services are found in cluster topology with respect to network mode
+func (mc *managementClient) endpoints(c context.Context, service managementService) ([]managementEndpoint, error) {
+	if service == managementServiceCluster {
+		return mc.clusterEndpoints(), nil
+	}
+
+	var nodeServices clusterNodeServices
+	if err := mc.send(c, http.MethodGet, mc.clusterEndpoints(), "/pools/default/nodeServices", nil, &nodeServices); err != nil {
+		return nil, err
+	}
+
+	key := managementServiceKeys[service][0]
+	if mc.scheme == "https" {
+		key = managementServiceKeys[service][1]
+	}
+
+	external := mc.useExternalNetwork(nodeServices)
+
+	var endpoints []managementEndpoint
+	for _, node := range nodeServices.NodesExt {
+		endpoint, ok := node.endpoint(key, external)
+		if !ok {
+			continue
+		}
+
+		// Single node cluster doesn't report hostname so seed node is used
+		if endpoint.host == "" {
+			endpoint.host = mc.addresses[0]
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("couchbase service: %s doesn't run on any node", key)
+	}
+
+	return endpoints, nil
+}
+
+// useExternalNetwork function decides if external alternate addresses are used. In auto mode external
+// addresses are used when seed node is one of external alternate addresses
+func (mc *managementClient) useExternalNetwork(nodeServices clusterNodeServices) bool {
+	switch mc.network {
+	case networkExternal:
+		return true
+	case networkDefault:
+		return false
+	}
+
+	for _, node := range nodeServices.NodesExt {
+		alternate, ok := node.AlternateAddresses[networkExternal]
+		if !ok {
+			continue
+		}
+
+		for _, address := range mc.addresses {
+			if alternate.Hostname == address {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// send function sends one request to couchbase service. When endpoint isn't reachable request is sent to next endpoint
+func (mc *managementClient) send(c context.Context, method string, endpoints []managementEndpoint, path string, form url.Values, result interface{}) error {
+	var err error
+
+	c = newLogContext(c, logSubsystemConnection, mc.password)
+
+	for _, endpoint := range endpoints {
+		var res *http.Response
+
+		start := time.Now()
+		address := fmt.Sprintf("%s://%s%s", mc.scheme, net.JoinHostPort(endpoint.host, strconv.Itoa(endpoint.port)), path)
+
+		spanContext, span := mc.tracer.Start(c, fmt.Sprintf("%s %s", method, path), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.full", address),
+			attribute.String("server.address", endpoint.host),
+			attribute.Int("server.port", endpoint.port),
+		))
+
+		res, err = mc.do(spanContext, method, endpoint, path, form)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			tflog.SubsystemWarn(c, logSubsystemConnection, "couchbase management request failed", map[string]interface{}{
+				"method":   method,
+				"url":      address,
+				"duration": time.Since(start).String(),
+				"error":    err.Error(),
+			})
+			if c.Err() != nil {
+				return err
+			}
+			continue
+		}
+
+		tflog.SubsystemDebug(c, logSubsystemConnection, "couchbase management request", map[string]interface{}{
+			"method":   method,
+			"url":      address,
+			"status":   res.StatusCode,
+			"duration": time.Since(start).String(),
+		})
+
+		err = mc.decode(method, path, res, result)
+
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		return err
+	}
+
+	return err
+}
+
+// do function sends request to couchbase service endpoint
+func (mc *managementClient) do(c context.Context, method string, endpoint managementEndpoint, path string, form url.Values) (*http.Response, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(c, method, fmt.Sprintf("%s://%s%s", mc.scheme, net.JoinHostPort(endpoint.host, strconv.Itoa(endpoint.port)), path), body)
+	if err != nil {
+		return nil, err
+	}
+	if !mc.certificateAuth {
+		req.SetBasicAuth(mc.username, mc.password)
+	}
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	return mc.client.Do(req)
+}
+
+// decode function decodes json response from couchbase service to result
+func (mc *managementClient) decode(method string, path string, res *http.Response, result interface{}) error {
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	resData, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return &ErrManagementRequest{
+			Method:     method,
+			Path:       path,
+			StatusCode: res.StatusCode,
+			Body:       string(resData),
+		}
+	}
+
+	if result == nil || len(resData) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resData, result)
+}
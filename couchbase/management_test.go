@@ -0,0 +1,167 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// testManagementClient function creates couchbase REST API client for test server
+func testManagementClient(t *testing.T, server *httptest.Server) *managementClient {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return newManagementClient(&Connection{
+		Address:    u.Hostname(),
+		ClientPort: port,
+		ClusterOptions: gocb.ClusterOptions{
+			Username: "Administrator",
+			Password: "password",
+		},
+	})
+}
+
+// TestManagementClient function verify
+// - json decoding and basic authentication
+// - retry of requests rejected during rebalance
+// - mapping of non 2xx status codes to management errors
+func TestManagementClient(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "Administrator" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/pools/default/buckets/bucket":
+			_, _ = fmt.Fprint(w, `{"conflictResolutionType":"lww"}`)
+		case "/rebalance":
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = fmt.Fprint(w, `"Rebalance running"`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	mc := testManagementClient(t, server)
+
+	var crt conflictResolutionType
+	if err := mc.get(context.Background(), managementServiceCluster, "/pools/default/buckets/bucket", &crt); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if crt.ConflictResolutionType != gocb.ConflictResolutionTypeTimestamp {
+		t.Fatalf("unexpected conflict resolution type: %s", crt.ConflictResolutionType)
+	}
+
+	if err := mc.post(context.Background(), managementServiceCluster, "/rebalance", url.Values{}, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("unexpected number of attempts: %d", attempts)
+	}
+
+	err := mc.get(context.Background(), managementServiceCluster, "/missing", nil)
+	if !errors.Is(err, ErrManagementNotFound) {
+		t.Fatalf("expected not found error, got: %s", err)
+	}
+
+	mc.password = "wrong"
+	err = mc.get(context.Background(), managementServiceCluster, "/pools/default/buckets/bucket", nil)
+	if !errors.Is(err, ErrManagementUnauthorized) {
+		t.Fatalf("expected unauthorized error, got: %s", err)
+	}
+}
+
+// TestManagementClientSeedFallback function verify
+// - seed nodes are parsed from connection string
+// - request is sent to next seed node when first seed node isn't reachable
+func TestManagementClientSeedFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"conflictResolutionType":"seqno"}`)
+	}))
+	defer server.Close()
+
+	mc := testManagementClient(t, server)
+	host := mc.addresses[0]
+
+	cc := &Connection{ConnectionString: fmt.Sprintf("couchbase://127.0.0.2,%s?network=default", host)}
+	mc.addresses = cc.seedHosts()
+	if len(mc.addresses) != 2 || mc.addresses[1] != host {
+		t.Fatalf("unexpected seed hosts: %v", mc.addresses)
+	}
+
+	var crt conflictResolutionType
+	if err := mc.get(context.Background(), managementServiceCluster, "/pools/default/buckets/bucket", &crt); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if crt.ConflictResolutionType != gocb.ConflictResolutionTypeSequenceNumber {
+		t.Fatalf("unexpected conflict resolution type: %s", crt.ConflictResolutionType)
+	}
+}
+
+// TestManagementClientExternalNetwork function verify
+// - service endpoints are resolved from node services
+// - external alternate addresses are used in auto mode when seed node is external address
+func TestManagementClientExternalNetwork(t *testing.T) {
+	mc := &managementClient{addresses: []string{"ext1.example.com"}, network: networkAuto, scheme: "http"}
+
+	nodeServices := clusterNodeServices{
+		NodesExt: []clusterNodeExt{
+			{
+				Hostname: "node1.internal",
+				Services: map[string]int{"mgmt": 8091, "n1ql": 8093},
+				AlternateAddresses: map[string]alternateAddress{
+					networkExternal: {Hostname: "ext1.example.com", Ports: map[string]int{"n1ql": 31093}},
+				},
+			},
+			{
+				Hostname: "node2.internal",
+				Services: map[string]int{"mgmt": 8091},
+			},
+		},
+	}
+
+	if !mc.useExternalNetwork(nodeServices) {
+		t.Fatalf("expected external network in auto mode")
+	}
+
+	endpoint, ok := nodeServices.NodesExt[0].endpoint("n1ql", true)
+	if !ok || endpoint.host != "ext1.example.com" || endpoint.port != 31093 {
+		t.Fatalf("unexpected external endpoint: %v", endpoint)
+	}
+
+	endpoint, ok = nodeServices.NodesExt[0].endpoint("n1ql", false)
+	if !ok || endpoint.host != "node1.internal" || endpoint.port != 8093 {
+		t.Fatalf("unexpected default endpoint: %v", endpoint)
+	}
+
+	if _, ok := nodeServices.NodesExt[1].endpoint("n1ql", true); ok {
+		t.Fatalf("node without query service returned endpoint")
+	}
+
+	mc.network = networkDefault
+	if mc.useExternalNetwork(nodeServices) {
+		t.Fatalf("expected default network")
+	}
+}
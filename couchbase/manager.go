@@ -0,0 +1,86 @@
+package couchbase
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// clusterManager interface contains cluster operations used by provider
+type clusterManager interface {
+	Diagnostics(opts *gocb.DiagnosticsOptions) (*gocb.DiagnosticsResult, error)
+	Close(opts *gocb.ClusterCloseOptions) error
+	Collections(bucketName string) collectionManager
+	WaitUntilBucketReady(bucketName string, timeout time.Duration) error
+}
+
+// bucketManager interface contains bucket operations used by provider. It is implemented by gocb.BucketManager
+type bucketManager interface {
+	CreateBucket(settings gocb.CreateBucketSettings, opts *gocb.CreateBucketOptions) error
+	GetBucket(bucketName string, opts *gocb.GetBucketOptions) (*gocb.BucketSettings, error)
+	UpdateBucket(settings gocb.BucketSettings, opts *gocb.UpdateBucketOptions) error
+	DropBucket(name string, opts *gocb.DropBucketOptions) error
+}
+
+// userManager interface contains user and group operations used by provider. It is implemented by gocb.UserManager
+type userManager interface {
+	UpsertUser(user gocb.User, opts *gocb.UpsertUserOptions) error
+	GetUser(name string, opts *gocb.GetUserOptions) (*gocb.UserAndMetadata, error)
+	DropUser(name string, opts *gocb.DropUserOptions) error
+	UpsertGroup(group gocb.Group, opts *gocb.UpsertGroupOptions) error
+	GetGroup(groupName string, opts *gocb.GetGroupOptions) (*gocb.Group, error)
+	DropGroup(groupName string, opts *gocb.DropGroupOptions) error
+}
+
+// collectionManager interface contains scope and collection operations used by provider.
+// It is implemented by gocb.CollectionManagerV2
+type collectionManager interface {
+	GetAllScopes(opts *gocb.GetAllScopesOptions) ([]gocb.ScopeSpec, error)
+	CreateScope(scopeName string, opts *gocb.CreateScopeOptions) error
+	DropScope(scopeName string, opts *gocb.DropScopeOptions) error
+	CreateCollection(scopeName string, collectionName string, settings *gocb.CreateCollectionSettings, opts *gocb.CreateCollectionOptions) error
+	DropCollection(scopeName string, collectionName string, opts *gocb.DropCollectionOptions) error
+}
+
+// queryIndexManager interface contains query index operations used by provider
+type queryIndexManager interface {
+	readQueryIndexByID(c context.Context, id string, timeout time.Duration) (*queryIndex, error)
+	readQueryIndexByName(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) (*queryIndex, error)
+	createPrimaryQueryIndex(c context.Context, indexName, keyspace string, with queryIndexWith, timeout time.Duration) error
+	createQueryIndex(c context.Context, indexName, keyspace string, fields []string, partitionBy []string, condition string, with queryIndexWith, timeout time.Duration) error
+	alterQueryIndexReplicaCount(c context.Context, indexName, keyspace string, numReplica int, nodes []string, timeout time.Duration) error
+	dropQueryIndexReplica(c context.Context, indexName, keyspace string, replicaID int, timeout time.Duration) error
+	buildQueryIndexes(c context.Context, keyspace string, indexNames []string, timeout time.Duration) error
+	dropQueryIndex(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) error
+	dropPrimaryQueryIndex(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) error
+}
+
+// managementAPI interface contains couchbase REST API requests used by provider. It is implemented by managementClient
+type managementAPI interface {
+	get(c context.Context, service managementService, path string, result interface{}) error
+	post(c context.Context, service managementService, path string, form url.Values, result interface{}) error
+	requestNode(c context.Context, method string, host string, path string, form url.Values, result interface{}) error
+}
+
+// gocbCluster struct implements cluster manager with gocb cluster
+type gocbCluster struct {
+	*gocb.Cluster
+}
+
+// Collections function returns collection manager of bucket
+func (cl *gocbCluster) Collections(bucketName string) collectionManager {
+	return cl.Bucket(bucketName).CollectionsV2()
+}
+
+// WaitUntilBucketReady function waits until bucket is online
+func (cl *gocbCluster) WaitUntilBucketReady(bucketName string, timeout time.Duration) error {
+	return cl.Bucket(bucketName).WaitUntilReady(timeout, &gocb.WaitUntilReadyOptions{DesiredState: gocb.ClusterStateOnline})
+}
+
+// gocbQueryIndexManager struct implements query index manager with query service DDL statements and gocb query index manager
+type gocbQueryIndexManager struct {
+	cluster *gocb.Cluster
+	indexes *gocb.QueryIndexManager
+}
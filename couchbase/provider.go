@@ -6,6 +6,7 @@ package couchbase
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io"
@@ -14,8 +15,10 @@ import (
 	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/couchbase/gocbcore/v10/connstr"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // Provider function provides structure for provider parameters
@@ -25,9 +28,18 @@ func Provider() *schema.Provider {
 		Schema: map[string]*schema.Schema{
 			providerAddress: {
 				Type:        schema.TypeString,
-				Required:    true,
+				Required:    false,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CB_ADDRESS", ""),
-				Description: "Couchbase address (without scheme)",
+				Description: "Couchbase address (without scheme). Required when connection_string isn't set",
+			},
+			providerConnectionString: {
+				Type:             schema.TypeString,
+				Required:         false,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("CB_CONNECTION_STRING", ""),
+				Description:      "Couchbase connection string with seed nodes and options (e.g. couchbases://node1,node2?network=external). Has priority before address and node_port",
+				ValidateDiagFunc: validateConnectionString(),
 			},
 			providerClientPort: {
 				Type:        schema.TypeInt,
@@ -37,22 +49,25 @@ func Provider() *schema.Provider {
 			},
 			providerNodePort: {
 				Type:        schema.TypeInt,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("CB_NODE_PORT", ""),
-				Description: "Couchbase port: node-to-node (usually for scheme couchbase 11210 or couchbases 112107)",
+				Required:    false,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CB_NODE_PORT", nil),
+				Description: "Couchbase port: node-to-node (usually for scheme couchbase 11210 or couchbases 112107). Required when connection_string isn't set",
 			},
 			providerUsername: {
 				Type:        schema.TypeString,
-				Required:    true,
+				Required:    false,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CB_USERNAME", ""),
-				Description: "Couchbase username",
+				Description: "Couchbase username. Required when client_cert isn't set",
 			},
 			providerPassword: {
 				Type:        schema.TypeString,
-				Required:    true,
+				Required:    false,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CB_PASSWORD", ""),
 				Sensitive:   true,
-				Description: "Couchbase password",
+				Description: "Couchbase password. Required when client_cert isn't set",
 			},
 			providerConnectionTimeout: {
 				Type:        schema.TypeInt,
@@ -90,18 +105,160 @@ func Provider() *schema.Provider {
 				DefaultFunc:      schema.EnvDefaultFunc("TLS_ROOT_CERT", ""),
 				Description:      "Path to TLS Root Certificate (in PEM format)",
 				ValidateDiagFunc: validateTLSRootCert(),
+				ConflictsWith:    []string{providerTLSRootCertPEM},
+			},
+			providerTLSRootCertPEM: {
+				Type:             schema.TypeString,
+				Required:         false,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("TLS_ROOT_CERT_PEM", ""),
+				Description:      "One or more TLS Root Certificates in PEM format",
+				ValidateDiagFunc: validateTLSRootCertPEM(),
+				ConflictsWith:    []string{providerTLSRootCert},
+			},
+			providerTLSRootCertSystemPool: {
+				Type:        schema.TypeBool,
+				Required:    false,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TLS_ROOT_CERT_SYSTEM_POOL", false),
+				Description: "Use system certificate pool for TLS connection. TLS Root Certificates are appended to system certificate pool instead of replacing it",
+			},
+			providerClientCert: {
+				Type:             schema.TypeString,
+				Required:         false,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("TLS_CLIENT_CERT", ""),
+				Description:      "Path to TLS client certificate or inline certificate (in PEM format) used for certificate authentication",
+				ValidateDiagFunc: validateTLSClientCert(),
+			},
+			providerClientKey: {
+				Type:             schema.TypeString,
+				Required:         false,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("TLS_CLIENT_KEY", ""),
+				Sensitive:        true,
+				Description:      "Path to TLS client private key or inline private key (in PEM format) used for certificate authentication",
+				ValidateDiagFunc: validateTLSClientKey(),
+			},
+			providerNetwork: {
+				Type:        schema.TypeString,
+				Required:    false,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CB_NETWORK", networkAuto),
+				Description: fmt.Sprintf(
+					"Network mode which selects node addresses for SDK and management requests\nAllowed values:\n%s\n%s\n%s\n",
+					networkAuto,
+					networkDefault,
+					networkExternal,
+				),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{networkAuto, networkDefault, networkExternal}, false)),
+			},
+			providerSDKLogging: {
+				Type:        schema.TypeBool,
+				Required:    false,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CB_SDK_LOGGING", false),
+				Description: "Forward couchbase SDK (gocb) internal logs to terraform logs (gocb log subsystem)",
+			},
+			providerDeletionProtection: {
+				Type:        schema.TypeBool,
+				Required:    false,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CB_DELETION_PROTECTION", false),
+				Description: "Default deletion protection of buckets, scopes and collections which don't set deletion_protection",
+			},
+			providerRetry: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Retry policy for operations which failed with transient cluster error (rebalance, temporary failure, service unavailable)",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						providerRetryMaxAttempts: {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          retryMaxAttempts,
+							Description:      "Maximum number of attempts of one operation",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+						},
+						providerRetryBackoff: {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          retryBackoff,
+							Description:      "Wait time in milliseconds before second attempt, wait time is doubled after every attempt",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+						},
+						providerRetryJitter: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Randomize wait time between attempts",
+						},
+						providerRetryMaxElapsed: {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          retryMaxElapsed,
+							Description:      "Maximum time in seconds spent by repeating one operation",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+						},
+					},
+				},
+			},
+			providerTracing: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "OpenTelemetry tracing of resource operations, couchbase management requests and SDK requests",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						providerTracingExporter: {
+							Type:             schema.TypeString,
+							Required:         true,
+							Description:      "Span exporter: otlp, file or stdout",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{tracingExporterOTLP, tracingExporterFile, tracingExporterStdout}, false)),
+						},
+						providerTracingEndpoint: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "OTLP http endpoint (host:port). OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used when it isn't set",
+						},
+						providerTracingInsecure: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Send spans to OTLP endpoint without TLS",
+						},
+						providerTracingFile: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Path to file where spans are written by file exporter",
+						},
+						providerTracingServiceName: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     tracingServiceName,
+							Description: "Service name of exported spans",
+						},
+					},
+				},
 			},
 		},
 
-		ResourcesMap: map[string]*schema.Resource{
+		ResourcesMap: traceResources(map[string]*schema.Resource{
 			"couchbase_bucket_manager":      resourceBucket(),
 			"couchbase_security_group":      resourceSecurityGroup(),
 			"couchbase_security_user":       resourceSecurityUser(),
 			"couchbase_primary_query_index": resourcePrimaryQueryIndex(),
 			"couchbase_query_index":         resourceQueryIndex(),
+			"couchbase_query_index_build":   resourceQueryIndexBuild(),
 			"couchbase_bucket_scope":        resourceScope(),
 			"couchbase_bucket_collection":   resourceCollection(),
-		},
+			"couchbase_alternate_address":   resourceAlternateAddress(),
+		}),
+
+		DataSourcesMap: traceResources(map[string]*schema.Resource{
+			"couchbase_cluster_version": dataSourceClusterVersion(),
+		}),
 
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -133,15 +290,117 @@ func getSaslMechanism(rawSaslMechanism string) ([]gocb.SaslMechanism, diag.Diagn
 	return saslMechanism, nil
 }
 
-// certificateManagement function add certificate from file to crypto/x509 certpool
-func certificateManagement(filePath string) (*x509.CertPool, diag.Diagnostics) {
-	tlsRootCAs := *x509.NewCertPool()
+// certificateManagement function adds PEM certificates to crypto/x509 certpool. When systemPool is true
+// certificates are appended to system certpool instead of empty certpool and data can be empty
+func certificateManagement(data []byte, systemPool bool) (*x509.CertPool, error) {
+	tlsRootCAs := x509.NewCertPool()
 
-	file, err := os.OpenFile(filePath, os.O_RDONLY, 0o600)
+	if systemPool {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		tlsRootCAs = pool
+
+		if len(data) == 0 {
+			return tlsRootCAs, nil
+		}
+	}
+
+	if ok := tlsRootCAs.AppendCertsFromPEM(data); !ok {
+		return nil, fmt.Errorf("cannot append certificate")
+	}
+
+	return tlsRootCAs, nil
+}
+
+// rootCertificateManagement function returns certpool from root certificate file or inline root certificates
+// and system certpool. Nil certpool is returned when TLS root certificates aren't configured
+func rootCertificateManagement(d *schema.ResourceData) (*x509.CertPool, diag.Diagnostics) {
+	certificatePath := d.Get(providerTLSRootCert).(string)
+	certificatePEM := d.Get(providerTLSRootCertPEM).(string)
+	if certificatePath != "" && certificatePEM != "" {
+		return nil, diag.Errorf("%s and %s can't be set together", providerTLSRootCert, providerTLSRootCertPEM)
+	}
+
+	var certificateData []byte
+	if certificatePath != "" {
+		data, err := readFile(certificatePath)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		certificateData = data
+	} else if certificatePEM != "" {
+		certificateData = []byte(certificatePEM)
+	}
+
+	systemPool := d.Get(providerTLSRootCertSystemPool).(bool)
+	if certificateData == nil && !systemPool {
+		return nil, nil
+	}
+
+	pool, err := certificateManagement(certificateData, systemPool)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
+	return pool, nil
+}
+
+// getRetryPolicy function returns retry policy from provider retry block or default retry policy
+func getRetryPolicy(d *schema.ResourceData) RetryPolicy {
+	rawRetry := d.Get(providerRetry).([]interface{})
+	if len(rawRetry) == 0 || rawRetry[0] == nil {
+		return defaultRetryPolicy()
+	}
+
+	retry := rawRetry[0].(map[string]interface{})
+
+	return RetryPolicy{
+		MaxAttempts: retry[providerRetryMaxAttempts].(int),
+		Backoff:     time.Duration(retry[providerRetryBackoff].(int)) * time.Millisecond,
+		Jitter:      retry[providerRetryJitter].(bool),
+		MaxElapsed:  time.Duration(retry[providerRetryMaxElapsed].(int)) * time.Second,
+	}
+}
+
+// configureTracing function creates tracer provider from provider tracing block and sets it to provider
+// operations and couchbase SDK
+func configureTracing(c context.Context, d *schema.ResourceData, cc *Connection) diag.Diagnostics {
+	rawTracing := d.Get(providerTracing).([]interface{})
+	if len(rawTracing) == 0 || rawTracing[0] == nil {
+		return nil
+	}
+
+	tracing := rawTracing[0].(map[string]interface{})
+
+	tracerProvider, tracerOutput, err := newTracerProvider(
+		c,
+		tracing[providerTracingExporter].(string),
+		tracing[providerTracingEndpoint].(string),
+		tracing[providerTracingInsecure].(bool),
+		tracing[providerTracingFile].(string),
+		tracing[providerTracingServiceName].(string),
+	)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	cc.Tracer = tracerProvider.Tracer(tracerName)
+	cc.tracerProvider = tracerProvider
+	cc.tracerOutput = tracerOutput
+	cc.ClusterOptions.Tracer = newOtelRequestTracer(tracerProvider)
+
+	return nil
+}
+
+// readFile function returns content of file
+func readFile(filePath string) ([]byte, error) {
+	file, err := os.OpenFile(filePath, os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
 	defer func() {
 		err := file.Close()
 		if err != nil {
@@ -149,37 +408,115 @@ func certificateManagement(filePath string) (*x509.CertPool, diag.Diagnostics) {
 		}
 	}()
 
-	data, err := io.ReadAll(file)
+	return io.ReadAll(file)
+}
+
+// readPEM function returns PEM data from inline value or from file when value is a path
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return readFile(value)
+}
+
+// clientCertificateManagement function loads TLS client certificate and private key for certificate authentication
+func clientCertificateManagement(certValue, keyValue string) (*tls.Certificate, diag.Diagnostics) {
+	certData, err := readPEM(certValue)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	keyData, err := readPEM(keyValue)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	ok := tlsRootCAs.AppendCertsFromPEM(data)
-	if !ok {
-		return nil, diag.Errorf("cannot append certificate")
+	certificate, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return nil, diag.Errorf("cannot load client certificate: %s", err)
+	}
+
+	return &certificate, nil
+}
+
+// providerAuthenticator function returns certificate authenticator when client certificate is set, otherwise
+// username and password are required. Certificate authentication works only over TLS connection which is used
+// with root certificate, system certificate pool or couchbases connection string scheme
+func providerAuthenticator(d *schema.ResourceData, scheme string) (gocb.Authenticator, diag.Diagnostics) {
+	clientCert := d.Get(providerClientCert).(string)
+	clientKey := d.Get(providerClientKey).(string)
+	if (clientCert == "") != (clientKey == "") {
+		return nil, diag.Errorf("%s and %s must be set together", providerClientCert, providerClientKey)
+	}
+
+	if clientCert == "" {
+		if d.Get(providerUsername).(string) == "" || d.Get(providerPassword).(string) == "" {
+			return nil, diag.Errorf("%s and %s must be set when %s isn't set", providerUsername, providerPassword, providerClientCert)
+		}
+		return nil, nil
+	}
+
+	if scheme != "couchbases" {
+		return nil, diag.Errorf("%s requires TLS connection, set %s, %s or %s or use couchbases connection string",
+			providerClientCert, providerTLSRootCert, providerTLSRootCertPEM, providerTLSRootCertSystemPool)
+	}
+
+	certificate, diags := clientCertificateManagement(clientCert, clientKey)
+	if diags != nil {
+		return nil, diags
 	}
 
-	return &tlsRootCAs, nil
+	return gocb.CertificateAuthenticator{ClientCertificate: certificate}, nil
 }
 
-func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	var (
 		tlsRootCAs *x509.CertPool
 		diags      diag.Diagnostics
 		scheme     string
 	)
 
-	certificatePath := d.Get(providerTLSRootCert).(string)
-	if certificatePath != "" {
-		tlsRootCAs, diags = certificateManagement(certificatePath)
-		if diags != nil {
-			return nil, diags
-		}
+	connectionString := d.Get(providerConnectionString).(string)
+	address := d.Get(providerAddress).(string)
+	nodePort := d.Get(providerNodePort).(int)
+	if connectionString == "" && (address == "" || nodePort == 0) {
+		return nil, diag.Errorf("%s or %s and %s must be set", providerConnectionString, providerAddress, providerNodePort)
+	}
+
+	tlsRootCAs, diags = rootCertificateManagement(d)
+	if diags != nil {
+		return nil, diags
+	}
+
+	if tlsRootCAs != nil {
 		scheme = "couchbases"
 	} else {
 		scheme = "couchbase"
 	}
 
+	// Scheme from connection string decides if TLS is used
+	network := d.Get(providerNetwork).(string)
+	if connectionString != "" {
+		spec, err := connstr.Parse(connectionString)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		scheme = spec.Scheme
+
+		if value, ok := spec.Options["network"]; ok && len(value) > 0 {
+			if network != networkAuto && value[0] != network {
+				return nil, diag.Errorf("%s: %s conflicts with network option in connection string: %s", providerNetwork, network, value[0])
+			}
+			network = value[0]
+		}
+	}
+
+	authenticator, diags := providerAuthenticator(d, scheme)
+	if diags != nil {
+		return nil, diags
+	}
+
 	saslMechanism, diags := getSaslMechanism(d.Get(providerAllowSaslMechanism).(string))
 	if diags != nil {
 		return nil, diags
@@ -189,13 +526,18 @@ func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{},
 		// Currently gocb v2 doesn't support some operations so we must use couchbase/couchbases and node-to-node
 		// ports for connection. You can read more about ports here:
 		// https://docs.couchbase.com/server/current/install/install-ports.html
-		Scheme:     scheme,
-		Address:    d.Get(providerAddress).(string),
-		NodePort:   d.Get(providerNodePort).(int),
-		ClientPort: d.Get(providerClientPort).(int),
+		Scheme:           scheme,
+		ConnectionString: connectionString,
+		Network:          network,
+		Retry:            getRetryPolicy(d),
+		Address:          address,
+		NodePort:         nodePort,
+		ClientPort:       d.Get(providerClientPort).(int),
 		ClusterOptions: gocb.ClusterOptions{
 			Username: d.Get(providerUsername).(string),
 			Password: d.Get(providerPassword).(string),
+			// Authenticator has priority before username and password when client certificate is set
+			Authenticator: authenticator,
 			TimeoutsConfig: gocb.TimeoutsConfig{
 				ManagementTimeout: time.Duration(d.Get(providerConnectionTimeout).(int)) * time.Second,
 			},
@@ -205,12 +547,34 @@ func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{},
 				AllowedSaslMechanisms: saslMechanism,
 			},
 		},
+		// Resources without deletion_protection attribute inherit provider default
+		DeletionProtection: d.Get(providerDeletionProtection).(bool),
 	}
 
-	_, diags = cc.ConnectionValidate()
-	if diags != nil {
+	if diags := configureTracing(ctx, d, cc); diags != nil {
 		return nil, diags
 	}
 
+	if d.Get(providerSDKLogging).(bool) {
+		gocb.SetLogger(newSDKLogger(ctx, cc.ClusterOptions.Password))
+	}
+
+	// Shared connection is created and validated during configuration so unreachable cluster fails before
+	// any resource operation. Resources reuse it and reconnect when it goes offline
+	if _, diags := cc.CouchbaseInitialization(ctx); diags != nil {
+		cc.Close()
+		return nil, diags
+	}
+
+	cc.detectClusterVersion(ctx)
+
+	// Close shared connection when terraform stops provider
+	if stopCtx, ok := schema.StopContext(ctx); ok {
+		go func() {
+			<-stopCtx.Done()
+			cc.Close()
+		}()
+	}
+
 	return cc, diags
 }
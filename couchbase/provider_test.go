@@ -2,10 +2,24 @@ package couchbase
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -45,3 +59,354 @@ func testAccPreCheck(t *testing.T) {
 	}
 
 }
+
+// testClientCertificate function returns self signed client certificate and private key in PEM format
+func testClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey}))
+}
+
+// TestProviderAuthenticator function verify
+// - username and password are required without client certificate
+// - client certificate is used without username and password over TLS connection without custom root certificate
+// - client certificate is rejected without TLS connection
+func TestProviderAuthenticator(t *testing.T) {
+	for _, name := range []string{"CB_USERNAME", "CB_PASSWORD", "TLS_CLIENT_CERT", "TLS_CLIENT_KEY"} {
+		t.Setenv(name, "")
+	}
+	certificate, key := testClientCertificate(t)
+
+	cases := []struct {
+		config      map[string]interface{}
+		scheme      string
+		certificate bool
+		err         string
+	}{
+		{map[string]interface{}{}, "couchbase", false, "username and password must be set when client_cert isn't set"},
+		{map[string]interface{}{providerUsername: "user"}, "couchbases", false, "username and password must be set"},
+		{map[string]interface{}{providerUsername: "user", providerPassword: "password"}, "couchbase", false, ""},
+		{map[string]interface{}{providerClientCert: certificate}, "couchbases", false, "client_cert and client_key must be set together"},
+		{map[string]interface{}{providerClientCert: certificate, providerClientKey: key}, "couchbase", false, "client_cert requires TLS connection"},
+		{map[string]interface{}{providerClientCert: certificate, providerClientKey: key}, "couchbases", true, ""},
+	}
+
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, tc.config)
+		authenticator, diags := providerAuthenticator(d, tc.scheme)
+		if tc.err != "" {
+			if !diags.HasError() || !strings.Contains(fmt.Sprint(diags), tc.err) {
+				t.Fatalf("expected error %q for %v over %s, got: %v", tc.err, tc.config, tc.scheme, diags)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Fatalf("unexpected error for %v over %s: %v", tc.config, tc.scheme, diags)
+		}
+		if _, ok := authenticator.(gocb.CertificateAuthenticator); ok != tc.certificate {
+			t.Fatalf("unexpected authenticator for %v over %s: %#v", tc.config, tc.scheme, authenticator)
+		}
+	}
+}
+
+// TestProviderRootCertificate function verify
+// - tls_root_cert reads certificate only from file and tls_root_cert_pem only from inline value
+// - tls_root_cert_system_pool enables TLS connection without custom root certificate
+// - TLS isn't used without root certificate configuration
+func TestProviderRootCertificate(t *testing.T) {
+	for _, name := range []string{"TLS_ROOT_CERT", "TLS_ROOT_CERT_PEM", "TLS_ROOT_CERT_SYSTEM_POOL"} {
+		t.Setenv(name, "")
+	}
+	certificate, _ := testClientCertificate(t)
+
+	certificatePath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(certificatePath, []byte(certificate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := validateTLSRootCert()(certificate, cty.Path{}); !diags.HasError() {
+		t.Fatalf("inline certificate is accepted as %s path", providerTLSRootCert)
+	}
+	if diags := validateTLSRootCert()(certificatePath, cty.Path{}); diags.HasError() {
+		t.Fatalf("certificate file isn't accepted as %s: %v", providerTLSRootCert, diags)
+	}
+	if diags := validateTLSRootCertPEM()(certificatePath, cty.Path{}); !diags.HasError() {
+		t.Fatalf("certificate path is accepted as %s", providerTLSRootCertPEM)
+	}
+
+	cases := []struct {
+		config map[string]interface{}
+		tls    bool
+		err    bool
+	}{
+		{map[string]interface{}{}, false, false},
+		{map[string]interface{}{providerTLSRootCertSystemPool: true}, true, false},
+		{map[string]interface{}{providerTLSRootCert: certificatePath}, true, false},
+		{map[string]interface{}{providerTLSRootCert: certificatePath, providerTLSRootCertSystemPool: true}, true, false},
+		{map[string]interface{}{providerTLSRootCertPEM: certificate}, true, false},
+		{map[string]interface{}{providerTLSRootCert: certificate}, false, true},
+		{map[string]interface{}{providerTLSRootCertPEM: certificatePath}, false, true},
+	}
+
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, tc.config)
+		pool, diags := rootCertificateManagement(d)
+		if diags.HasError() != tc.err {
+			t.Fatalf("unexpected result for %v: %v", tc.config, diags)
+		}
+		if (pool != nil) != tc.tls {
+			t.Fatalf("unexpected root certificates for %v: %v", tc.config, pool)
+		}
+	}
+}
+
+const testServerResources = `
+resource "couchbase_bucket_manager" "bucket" {
+    name            = "testServerResources_bucket"
+    ram_quota_mb    = 1024
+    storage_backend = "magma"
+}
+
+resource "couchbase_bucket_scope" "scope" {
+    name   = "testServerResources_scope"
+    bucket = couchbase_bucket_manager.bucket.name
+}
+
+resource "couchbase_bucket_collection" "collection" {
+    name       = "testServerResources_collection"
+    scope      = couchbase_bucket_scope.scope.name
+    bucket     = couchbase_bucket_manager.bucket.name
+    max_expire = 20
+    history    = true
+}
+
+resource "couchbase_security_group" "group" {
+    name        = "testServerResources_group"
+    description = "Group"
+
+    role {
+        name   = "bucket_admin"
+        bucket = couchbase_bucket_manager.bucket.name
+    }
+}
+
+resource "couchbase_security_user" "user" {
+    username = "testServerResources_user"
+    password = "password"
+    groups   = [couchbase_security_group.group.name]
+
+    role {
+        name       = "data_reader"
+        bucket     = couchbase_bucket_manager.bucket.name
+        scope      = couchbase_bucket_scope.scope.name
+        collection = couchbase_bucket_collection.collection.name
+    }
+}
+
+resource "couchbase_primary_query_index" "primary" {
+    name   = "testServerResources_primary"
+    bucket = couchbase_bucket_manager.bucket.name
+}
+
+resource "couchbase_query_index" "index" {
+    name       = "testServerResources_index"
+    bucket     = couchbase_bucket_manager.bucket.name
+    scope      = couchbase_bucket_scope.scope.name
+    collection = couchbase_bucket_collection.collection.name
+    fields     = ["` + "`action`" + `"]
+}
+
+resource "couchbase_query_index_build" "build" {
+    bucket    = couchbase_bucket_manager.bucket.name
+    index_ids = [couchbase_primary_query_index.primary.id]
+}
+`
+
+// TestServerProvider function verify with fake couchbase server
+// - all resources are created with terraform test steps
+func TestServerProvider(t *testing.T) {
+	testFakeServerPreCheck(t)
+	s := newFakeServer(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: s.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: s.providerConfig() + testServerResources,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_bucket_collection.collection", "history", "true"),
+					resource.TestCheckResourceAttr("couchbase_security_user.user", "groups.0", "testServerResources_group"),
+					resource.TestCheckResourceAttr("couchbase_query_index.index", "status", "Created"),
+					resource.TestCheckResourceAttr("couchbase_query_index_build.build", "index_ids.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// TestServerProviderConfigure function verify with fake couchbase server
+// - shared connection is created during provider configuration
+// - provider configuration fails when cluster isn't reachable
+func TestServerProviderConfigure(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+	if cc.configuration == nil {
+		t.Fatalf("shared connection isn't created during provider configuration")
+	}
+
+	listener, err := net.Listen("tcp", fakeServerHost+":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err := listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := s.settings()
+	settings[providerNodePort] = port
+	settings[providerConnectionTimeout] = 1
+	if diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(settings)); !diags.HasError() {
+		t.Fatalf("provider is configured with unreachable cluster")
+	}
+}
+
+// TestServerResources function verify with fake couchbase server that resources work with gocb and REST API
+// requests of real couchbase
+// - scope, collection, security group and user are created and read back without diff
+// - primary and secondary query indexes are created, imported and built
+// - alternate address is set on cluster node
+// - cluster version is detected during provider configuration
+func TestServerResources(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+
+	if cc.Version.String() != "7.6.2" || !cc.Version.Enterprise {
+		t.Fatalf("unexpected cluster version: %+v", cc.Version)
+	}
+
+	testResourceApply(t, resourceBucket(), nil, map[string]interface{}{
+		keyBucketName:           "bucket",
+		keyBucketQuota:          1024,
+		keyBucketStorageBackend: "magma",
+	}, cc)
+
+	resources := []struct {
+		resource *schema.Resource
+		config   map[string]interface{}
+		expected map[string]string
+	}{
+		{
+			resource: resourceScope(),
+			config:   map[string]interface{}{keyScopeBucketName: "bucket", keyScopeName: "scope"},
+			expected: map[string]string{"id": "bucket/scope"},
+		},
+		{
+			resource: resourceCollection(),
+			config: map[string]interface{}{
+				keyCollectionBucketName: "bucket",
+				keyCollectionScopeName:  "scope",
+				keyCollectionName:       "collection",
+				keyCollectionMaxExpiry:  20,
+				keyCollectionHistory:    true,
+			},
+			expected: map[string]string{"id": "bucket/scope/collection", keyCollectionMaxExpiry: "20", keyCollectionHistory: "true"},
+		},
+		{
+			resource: resourceSecurityGroup(),
+			config: map[string]interface{}{
+				keySecurityGroupName:        "group",
+				keySecurityGroupDescription: "Group",
+				keySecurityGroupRole:        []interface{}{map[string]interface{}{keySecurityGroupRoleName: "bucket_admin", keySecurityGroupRoleBucket: "bucket"}},
+			},
+			expected: map[string]string{"id": "group", "role.#": "1"},
+		},
+		{
+			resource: resourceSecurityUser(),
+			config: map[string]interface{}{
+				keySecurityUserUsername: "user",
+				keySecurityUserPassword: "password",
+				keySecurityUserGroup:    []interface{}{"group"},
+				keySecurityUserRole: []interface{}{map[string]interface{}{
+					keySecurityGroupRoleName:       "data_reader",
+					keySecurityGroupRoleBucket:     "bucket",
+					keySecurityGroupRoleScope:      "scope",
+					keySecurityGroupRoleCollection: "collection",
+				}},
+			},
+			expected: map[string]string{"id": "user", "groups.0": "group", "role.#": "1"},
+		},
+		{
+			resource: resourcePrimaryQueryIndex(),
+			config:   map[string]interface{}{keyPrimaryQueryIndexName: "primary", keyPrimaryQueryIndexBucket: "bucket"},
+			expected: map[string]string{keyPrimaryQueryIndexStatus: "Created", "hosts.#": "1"},
+		},
+		{
+			resource: resourceQueryIndex(),
+			config: map[string]interface{}{
+				keyQueryIndexName:         "index",
+				keyQueryIndexBucket:       "bucket",
+				keyQueryIndexScope:        "scope",
+				keyQueryIndexCollection:   "collection",
+				keyQueryIndexFields:       []interface{}{"`action`"},
+				keyQueryIndexPartitionBy:  []interface{}{"`action`"},
+				keyQueryIndexNumPartition: 4,
+			},
+			expected: map[string]string{keyQueryIndexCollection: "collection", "partition_by.0": "`action`", keyQueryIndexNumPartition: "4"},
+		},
+		{
+			resource: resourceAlternateAddress(),
+			config: map[string]interface{}{
+				keyAlternateAddressNode:     fakeServerHost,
+				keyAlternateAddressHostname: "external.example.com",
+				keyAlternateAddressPorts:    map[string]interface{}{"mgmt": 18091},
+			},
+			expected: map[string]string{keyAlternateAddressHostname: "external.example.com", "ports.mgmt": "18091"},
+		},
+	}
+
+	states := make([]*terraform.InstanceState, len(resources))
+	for i, tc := range resources {
+		states[i] = testResourceApply(t, tc.resource, nil, tc.config, cc)
+		testCheckState(t, states[i], tc.expected)
+		testCheckNoDiff(t, tc.resource, states[i], tc.config, cc)
+	}
+
+	imported := testResourceImport(t, resourceQueryIndex(), "bucket/scope/collection/index", cc)
+	testCheckState(t, imported, map[string]string{"id": states[5].ID, "fields.0": "`action`"})
+
+	build := testResourceApply(t, resourceQueryIndexBuild(), nil, map[string]interface{}{
+		keyQueryIndexBuildBucket:  "bucket",
+		keyQueryIndexBuildIndexes: []interface{}{states[4].ID},
+	}, cc)
+	testCheckState(t, build, map[string]string{"index_ids.#": "1"})
+	testCheckState(t, testResourceRefresh(t, resources[4].resource, states[4], cc), map[string]string{keyPrimaryQueryIndexStatus: "Ready"})
+
+	for i := len(resources) - 1; i >= 0; i-- {
+		testResourceDestroy(t, resources[i].resource, states[i], cc)
+		testCheckRemoved(t, testResourceRefresh(t, resources[i].resource, states[i], cc))
+	}
+}
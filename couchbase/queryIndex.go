@@ -2,17 +2,33 @@ package couchbase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// queryIndexBusyErrors contains parts of indexer error messages which are returned when indexer
+// is processing another index and DDL statement can be repeated later
+var queryIndexBusyErrors = []string{
+	"another index creation is in progress",
+	"build already in progress",
+	"due to another concurrent create index request",
+	"rebalance in progress",
+}
+
 // queryIndex custom query index structure.
 // gocb v2 doesn't have ID in structure
 type queryIndex struct {
+	BucketID    string   `json:"bucket_id"`
 	Condition   string   `json:"condition"`
 	DatastoreID string   `json:"datastore_id"`
 	ID          string   `json:"id"`
@@ -21,10 +37,76 @@ type queryIndex struct {
 	KeyspaceID  string   `json:"keyspace_id"`
 	Name        string   `json:"name"`
 	NamespaceID string   `json:"namespace_id"`
+	Partition   string   `json:"partition"`
+	ScopeID     string   `json:"scope_id"`
 	State       string   `json:"state"`
 	Using       string   `json:"using"`
 }
 
+// bucketName function returns bucket name of query index. Collection indexes have bucket name in bucket_id
+// and collection name in keyspace_id
+func (idx *queryIndex) bucketName() string {
+	if idx.BucketID != "" {
+		return idx.BucketID
+	}
+	return idx.KeyspaceID
+}
+
+// collectionName function returns collection name of query index or empty string for bucket indexes
+func (idx *queryIndex) collectionName() string {
+	if idx.BucketID != "" {
+		return idx.KeyspaceID
+	}
+	return ""
+}
+
+// keyspace function returns query keyspace of query index
+func (idx *queryIndex) keyspace() string {
+	return getKeyspace(idx.bucketName(), idx.ScopeID, idx.collectionName())
+}
+
+// isDefaultCollection function checks if scope and collection point to bucket default collection
+// which is represented as bucket keyspace in system:indexes
+func isDefaultCollection(scopeName, collectionName string) bool {
+	return (scopeName == "" && collectionName == "") || (scopeName == "_default" && collectionName == "_default")
+}
+
+// getKeyspace function returns query keyspace for bucket or for collection when scope and collection are set
+func getKeyspace(bucketName, scopeName, collectionName string) string {
+	if isDefaultCollection(scopeName, collectionName) {
+		return fmt.Sprintf("`%s`", bucketName)
+	}
+	return fmt.Sprintf("`%s`.`%s`.`%s`", bucketName, scopeName, collectionName)
+}
+
+// setQueryIndexKeyspace function sets bucket, scope and collection of query index to resource data.
+// Index in bucket default collection configured with "_default" scope and collection keeps configured values
+func setQueryIndexKeyspace(d *schema.ResourceData, idx *queryIndex, keyBucket, keyScope, keyCollection string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	bucketName := idx.bucketName()
+	scopeName := idx.ScopeID
+	collectionName := idx.collectionName()
+
+	if isDefaultCollection(scopeName, collectionName) &&
+		isDefaultCollection(d.Get(keyScope).(string), d.Get(keyCollection).(string)) {
+		scopeName = d.Get(keyScope).(string)
+		collectionName = d.Get(keyCollection).(string)
+	}
+
+	if err := d.Set(keyBucket, bucketName); err != nil {
+		diags = append(diags, *diagForValueSet(keyBucket, bucketName, err))
+	}
+	if err := d.Set(keyScope, scopeName); err != nil {
+		diags = append(diags, *diagForValueSet(keyScope, scopeName, err))
+	}
+	if err := d.Set(keyCollection, collectionName); err != nil {
+		diags = append(diags, *diagForValueSet(keyCollection, collectionName, err))
+	}
+
+	return diags
+}
+
 // convertFiedsToList function convert list of fields interfaces to list of strings
 func convertFieldsToList(rawFields []interface{}) ([]string, error) {
 	var fields []string
@@ -39,6 +121,81 @@ func convertFieldsToList(rawFields []interface{}) ([]string, error) {
 	return fields, nil
 }
 
+// parsePartitionBy function parses partition expressions from system:indexes partition value e.g. HASH(`a`, `b`).
+// Expressions are separated by commas which are not nested in parentheses, quotes or backticks
+func parsePartitionBy(partition string) []string {
+	var (
+		expressions []string
+		depth       int
+		quote       rune
+		start       int
+	)
+
+	partition = strings.TrimSpace(partition)
+	if !strings.HasPrefix(strings.ToUpper(partition), "HASH(") || !strings.HasSuffix(partition, ")") {
+		return expressions
+	}
+	partition = partition[len("HASH(") : len(partition)-1]
+
+	for i, r := range partition {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '`' || r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			expressions = append(expressions, strings.TrimSpace(partition[start:i]))
+			start = i + 1
+		}
+	}
+
+	if last := strings.TrimSpace(partition[start:]); last != "" {
+		expressions = append(expressions, last)
+	}
+	return expressions
+}
+
+// normalizeQueryIndexExpression function removes backticks, whitespace and parentheses around whole expression which
+// are added by indexer to stored index expressions, so expression from configuration can be compared with it
+func normalizeQueryIndexExpression(expression string) string {
+	expression = strings.Map(func(r rune) rune {
+		if r == '`' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, expression)
+
+	for strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
+		depth := 0
+		for i, r := range expression {
+			if r == '(' {
+				depth++
+			} else if r == ')' {
+				depth--
+			}
+			// Parenthesis at start closes before end of expression e.g. (a)+(b)
+			if depth == 0 && i < len(expression)-1 {
+				return expression
+			}
+		}
+		expression = expression[1 : len(expression)-1]
+	}
+
+	return expression
+}
+
+// suppressQueryIndexExpressionDiff function suppresses diff of index expressions which are equal after
+// normalization of indexer formatting
+func suppressQueryIndexExpressionDiff(_, oldValue, newValue string, _ *schema.ResourceData) bool {
+	return normalizeQueryIndexExpression(oldValue) == normalizeQueryIndexExpression(newValue)
+}
+
 // getDeferredState function return string based on deferred bool value
 func getDeferredState(state bool) string {
 	if state {
@@ -48,11 +205,13 @@ func getDeferredState(state bool) string {
 }
 
 // readQueryIndexByID function read query indexes based on ID
-func (cc *Configuration) readQueryIndexByID(id string) (*queryIndex, error) {
+func (qm *gocbQueryIndexManager) readQueryIndexByID(c context.Context, id string, timeout time.Duration) (*queryIndex, error) {
 	q := "SELECT `indexes`.* FROM system:indexes WHERE id=? AND `using`=\"gsi\""
-	rows, err := cc.Cluster.Query(q, &gocb.QueryOptions{
+	rows, err := qm.cluster.Query(q, &gocb.QueryOptions{
 		PositionalParameters: []interface{}{id},
 		Readonly:             true,
+		Timeout:              timeout,
+		ParentSpan:           requestSpan(c),
 	})
 	if err != nil {
 		return nil, err
@@ -84,12 +243,22 @@ func (cc *Configuration) readQueryIndexByID(id string) (*queryIndex, error) {
 	return index, nil
 }
 
-// readQueryIndexByName function read query indexes based on index name and bucket name
-func (cc *Configuration) readQueryIndexByName(indexName, bucketName string) (*queryIndex, error) {
-	q := "SELECT `indexes`.* FROM system:indexes WHERE keyspace_id=? AND name=? AND `using`=\"gsi\""
-	rows, err := cc.Cluster.Query(q, &gocb.QueryOptions{
-		PositionalParameters: []interface{}{bucketName, indexName},
+// readQueryIndexByName function read query indexes based on index name and bucket name.
+// Scope and collection names are used when index is not created in bucket default collection
+func (qm *gocbQueryIndexManager) readQueryIndexByName(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) (*queryIndex, error) {
+	q := "SELECT `indexes`.* FROM system:indexes WHERE keyspace_id=? AND bucket_id IS MISSING AND name=? AND `using`=\"gsi\""
+	params := []interface{}{bucketName, indexName}
+
+	if !isDefaultCollection(scopeName, collectionName) {
+		q = "SELECT `indexes`.* FROM system:indexes WHERE bucket_id=? AND scope_id=? AND keyspace_id=? AND name=? AND `using`=\"gsi\""
+		params = []interface{}{bucketName, scopeName, collectionName, indexName}
+	}
+
+	rows, err := qm.cluster.Query(q, &gocb.QueryOptions{
+		PositionalParameters: params,
 		Readonly:             true,
+		Timeout:              timeout,
+		ParentSpan:           requestSpan(c),
 	})
 	if err != nil {
 		return nil, err
@@ -115,48 +284,74 @@ func (cc *Configuration) readQueryIndexByName(indexName, bucketName string) (*qu
 	}()
 
 	if index == nil {
-		return nil, fmt.Errorf("index not found index: %s bucket: %s; %w", indexName, bucketName, gocb.ErrIndexNotFound)
+		return nil, fmt.Errorf("index not found index: %s keyspace: %s; %w", indexName, getKeyspace(bucketName, scopeName, collectionName), gocb.ErrIndexNotFound)
 	}
 
 	return index, nil
 }
 
-// createPrimaryQueryIndex custom function which support primary query index creation with deferred state, number of replicas
-func (cc *Configuration) createPrimaryQueryIndex(indexName, bucketName string, deferred bool, numReplica int) error {
-	q := fmt.Sprintf("CREATE PRIMARY INDEX `%s` ON `%s` WITH {\"defer_build\":%t, \"num_replica\":%d}", indexName, bucketName, deferred, numReplica)
-	rows, err := cc.Cluster.Query(q, nil)
-	if err != nil {
-		return err
-	}
+// queryIndexWith custom structure for WITH clause of query index DDL statements
+type queryIndexWith struct {
+	DeferBuild   bool     `json:"defer_build"`
+	NumReplica   int      `json:"num_replica"`
+	NumPartition int      `json:"num_partition,omitempty"`
+	Nodes        []string `json:"nodes,omitempty"`
+}
 
-	defer func() {
-		err := rows.Close()
-		if err != nil {
-			return
-		}
-	}()
+// String function returns WITH clause of query index DDL statement
+func (w queryIndexWith) String() string {
+	// Marshal of structure with bool, int and string fields can't fail
+	data, _ := json.Marshal(w)
+	return fmt.Sprintf("WITH %s", data)
+}
 
-	return nil
+// createPrimaryQueryIndex custom function which support primary query index creation with deferred state, number of replicas
+func (qm *gocbQueryIndexManager) createPrimaryQueryIndex(c context.Context, indexName, keyspace string, with queryIndexWith, timeout time.Duration) error {
+	q := fmt.Sprintf("CREATE PRIMARY INDEX `%s` ON %s %s", indexName, keyspace, with)
+	return qm.runQueryIndexDDL(c, q, timeout)
 }
 
-// createQueryIndex custom function which support query index creation with fields parameters and conditions, deferred state, number of replicas
-func (cc *Configuration) createQueryIndex(indexName, bucketName string, fields []string, condition string, deferred bool, numReplica int) error {
+// createQueryIndex custom function which support query index creation with fields parameters, hash partitioning and conditions,
+// deferred state, number of replicas and partitions
+func (qm *gocbQueryIndexManager) createQueryIndex(c context.Context, indexName, keyspace string, fields []string, partitionBy []string, condition string, with queryIndexWith, timeout time.Duration) error {
+	var partition string
+
 	if len(fields) == 0 {
 		return fmt.Errorf("you must specify at least one field to index")
 	}
 
+	if len(partitionBy) > 0 {
+		partition = fmt.Sprintf("PARTITION BY HASH(%s)", strings.Join(partitionBy, ","))
+	}
+
 	if condition != "" {
 		condition = fmt.Sprintf("WHERE %s", condition)
 	} else {
 		condition = ""
 	}
 
-	q := fmt.Sprintf("CREATE INDEX `%s` ON `%s`(%s) %s WITH {\"defer_build\":%t, \"num_replica\":%d}", indexName, bucketName, strings.Join(fields, ","), condition, deferred, numReplica)
-	rows, err := cc.Cluster.Query(q, nil)
+	q := fmt.Sprintf("CREATE INDEX `%s` ON %s(%s) %s %s %s", indexName, keyspace, strings.Join(fields, ","), partition, condition, with)
+	return qm.runQueryIndexDDL(c, q, timeout)
+}
+
+// runQueryIndexDDL function sends query index DDL statement to query service and logs exact statement
+func (qm *gocbQueryIndexManager) runQueryIndexDDL(c context.Context, statement string, timeout time.Duration) error {
+	start := time.Now()
+
+	tflog.SubsystemDebug(c, logSubsystemIndex, "sending query index DDL statement", map[string]interface{}{
+		"statement": statement,
+	})
+
+	rows, err := qm.cluster.Query(statement, &gocb.QueryOptions{Timeout: timeout, ParentSpan: requestSpan(c)})
 	if err != nil {
+		tflog.SubsystemDebug(c, logSubsystemIndex, "query index DDL statement failed", map[string]interface{}{
+			"statement": statement,
+			"duration":  time.Since(start).String(),
+			"error":     err.Error(),
+		})
 		return err
 	}
-	
+
 	defer func() {
 		err := rows.Close()
 		if err != nil {
@@ -164,33 +359,206 @@ func (cc *Configuration) createQueryIndex(indexName, bucketName string, fields [
 		}
 	}()
 
+	tflog.SubsystemDebug(c, logSubsystemIndex, "query index DDL statement finished", map[string]interface{}{
+		"statement": statement,
+		"duration":  time.Since(start).String(),
+	})
+
 	return nil
 }
 
-// parseID function which parse id and number of index replicas during import
-func parseID(id string) (string, int, error) {
-	results := strings.Split(id, ",")
-	if len(results) != 2 {
-		return "", 0, fmt.Errorf("cannot parse id during import id: %s", id)
+// isQueryIndexBusyError function checks if query index DDL failed because indexer is busy with another index
+func isQueryIndexBusyError(err error) bool {
+	message := strings.ToLower(err.Error())
+
+	for _, busy := range queryIndexBusyErrors {
+		if strings.Contains(message, busy) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryIndexDDL function runs query index DDL statement with provider retry policy for transient errors and
+// repeats it until timeout expires while indexer is busy with another index. Index build can take several minutes
+// so busy indexer isn't limited by retry policy. Connection.queryIndexMutex is held only during DDL statement so
+// DDL statements are not sent to cluster concurrently and waiting for index state doesn't block other indexes.
+// Cached indexer status is dropped after DDL statement
+func (cc *Connection) queryIndexDDL(c context.Context, timeout time.Duration, ddl func() error) error {
+	cc.queryIndexMutex.Lock()
+	defer cc.queryIndexMutex.Unlock()
+	defer cc.invalidateIndexStatus()
+
+	return waitFor(c, logSubsystemIndex, "query index DDL", timeout, func() *retry.RetryError {
+		err := cc.retryOperation(c, ddl)
+		if err != nil && isQueryIndexBusyError(err) {
+			return retry.RetryableError(err)
+		}
+
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+
+		return nil
+	})
+}
+
+// queryIndexAlter custom structure for WITH clause of ALTER INDEX statement
+type queryIndexAlter struct {
+	Action     string   `json:"action"`
+	NumReplica *int     `json:"num_replica,omitempty"`
+	ReplicaID  *int     `json:"replicaId,omitempty"`
+	Nodes      []string `json:"nodes,omitempty"`
+}
+
+// String function returns WITH clause of ALTER INDEX statement
+func (a queryIndexAlter) String() string {
+	// Marshal of structure with int and string fields can't fail
+	data, _ := json.Marshal(a)
+	return fmt.Sprintf("WITH %s", data)
+}
+
+// alterQueryIndexReplicaCount custom function which changes number of query index replicas without index rebuild.
+// Replicas are placed on nodes when nodes are set
+func (qm *gocbQueryIndexManager) alterQueryIndexReplicaCount(c context.Context, indexName, keyspace string, numReplica int, nodes []string, timeout time.Duration) error {
+	alter := queryIndexAlter{Action: "replica_count", NumReplica: &numReplica, Nodes: nodes}
+	q := fmt.Sprintf("ALTER INDEX `%s` ON %s %s", indexName, keyspace, alter)
+	return qm.runQueryIndexDDL(c, q, timeout)
+}
+
+// dropQueryIndexReplica custom function which drops one query index replica with replica ID without index rebuild
+func (qm *gocbQueryIndexManager) dropQueryIndexReplica(c context.Context, indexName, keyspace string, replicaID int, timeout time.Duration) error {
+	alter := queryIndexAlter{Action: "drop_replica", ReplicaID: &replicaID}
+	q := fmt.Sprintf("ALTER INDEX `%s` ON %s %s", indexName, keyspace, alter)
+	return qm.runQueryIndexDDL(c, q, timeout)
+}
+
+// dropQueryIndex function drops query index from bucket or from collection when scope and collection are set
+func (qm *gocbQueryIndexManager) dropQueryIndex(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) error {
+	opts := &gocb.DropQueryIndexOptions{
+		IgnoreIfNotExists: true,
+		Timeout:           timeout,
+		ParentSpan:        requestSpan(c),
+	}
+
+	tflog.SubsystemDebug(c, logSubsystemIndex, "dropping query index", map[string]interface{}{
+		"name":       indexName,
+		"bucket":     bucketName,
+		"scope":      scopeName,
+		"collection": collectionName,
+	})
+
+	if isDefaultCollection(scopeName, collectionName) {
+		return qm.indexes.DropIndex(bucketName, indexName, opts)
 	}
 
-	sub, err := strconv.Atoi(results[1])
+	return qm.cluster.Bucket(bucketName).Scope(scopeName).Collection(collectionName).QueryIndexes().DropIndex(indexName, opts)
+}
+
+// dropPrimaryQueryIndex function drops primary query index from bucket or from collection when scope and collection are set
+func (qm *gocbQueryIndexManager) dropPrimaryQueryIndex(c context.Context, indexName, bucketName, scopeName, collectionName string, timeout time.Duration) error {
+	opts := &gocb.DropPrimaryQueryIndexOptions{
+		IgnoreIfNotExists: true,
+		CustomName:        indexName,
+		Timeout:           timeout,
+		ParentSpan:        requestSpan(c),
+	}
+
+	tflog.SubsystemDebug(c, logSubsystemIndex, "dropping primary query index", map[string]interface{}{
+		"name":       indexName,
+		"bucket":     bucketName,
+		"scope":      scopeName,
+		"collection": collectionName,
+	})
+
+	if isDefaultCollection(scopeName, collectionName) {
+		return qm.indexes.DropPrimaryIndex(bucketName, opts)
+	}
+
+	return qm.cluster.Bucket(bucketName).Scope(scopeName).Collection(collectionName).QueryIndexes().DropPrimaryIndex(opts)
+}
+
+// buildQueryIndexes custom function which builds deferred query indexes in keyspace with one statement
+func (qm *gocbQueryIndexManager) buildQueryIndexes(c context.Context, keyspace string, indexNames []string, timeout time.Duration) error {
+	if len(indexNames) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, name := range indexNames {
+		names = append(names, fmt.Sprintf("`%s`", name))
+	}
+
+	q := fmt.Sprintf("BUILD INDEX ON %s(%s)", keyspace, strings.Join(names, ","))
+	err := qm.runQueryIndexDDL(c, q, timeout)
+	// Indexer accepts build request and finishes it later when other index build is running
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "will retry building in the background") {
+		return nil
+	}
+
+	return err
+}
+
+// parseID function which parse query index reference during import. Number of index replicas suffix (",1")
+// from previous import format is accepted and ignored because number of replicas is read from indexer
+func parseID(id string) (string, error) {
+	reference, replica, found := strings.Cut(id, ",")
+	if !found {
+		return id, nil
+	}
+
+	if _, err := strconv.Atoi(replica); err != nil {
+		return "", fmt.Errorf("cannot convert part of id to int id: %s", id)
+	}
+
+	return reference, nil
+}
+
+// resolveQueryIndexID function returns query index ID from import reference. Reference is index ID or
+// index name with keyspace in format bucket/index_name or bucket/scope/collection/index_name
+func resolveQueryIndexID(c context.Context, m interface{}, reference string, timeout time.Duration) (string, error) {
+	var bucketName, scopeName, collectionName, indexName string
+
+	names := strings.Split(reference, "/")
+	switch len(names) {
+	case 1:
+		return reference, nil
+	case 2:
+		bucketName, indexName = names[0], names[1]
+	case 4:
+		bucketName, scopeName, collectionName, indexName = names[0], names[1], names[2], names[3]
+	default:
+		return "", fmt.Errorf("cannot parse query index reference during import: %s", reference)
+	}
+
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
+	if diags != nil {
+		return "", fmt.Errorf("%s%s", diags[0].Summary, diags[0].Detail)
+	}
+
+	idx, err := couchbase.QueryIndexManager.readQueryIndexByName(c, indexName, bucketName, scopeName, collectionName, timeout)
 	if err != nil {
-		return "", 0, fmt.Errorf("cannot convert part of id to int id: %s", id)
+		return "", err
 	}
 
-	return results[0], sub, nil
+	return idx.ID, nil
 }
 
 // importQueryIndex custom terraform resource import function
-func importQueryIndex(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+func importQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+
+	reference, err := parseID(d.Id())
+	if err != nil {
+		return nil, err
+	}
 
-	id, replica, err := parseID(d.Id())
+	id, err := resolveQueryIndexID(c, m, reference, d.Timeout(schema.TimeoutRead))
 	if err != nil {
 		return nil, err
 	}
 
-	if err = d.Set(keyQueryIndexNumReplica, replica); err != nil {
+	// Deferred state is known only during creation so imported index gets default value
+	if err = d.Set(keyQueryIndexDeferred, true); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,135 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceAlternateAddress() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createAlternateAddress,
+		ReadContext:   readAlternateAddress,
+		UpdateContext: updateAlternateAddress,
+		DeleteContext: deleteAlternateAddress,
+		Description:   "Manage external alternate address of couchbase node",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			keyAlternateAddressNode: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Hostname of couchbase node where alternate address is configured. Node must be reachable on provider client port",
+			},
+			keyAlternateAddressHostname: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "External hostname of couchbase node",
+			},
+			keyAlternateAddressPorts: {
+				Type: schema.TypeMap,
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+				Optional: true,
+				Description: fmt.Sprintf("External ports of couchbase node services. Allowed services: %s",
+					strings.Join(alternateAddressPorts, ", "),
+				),
+				ValidateDiagFunc: validateAlternateAddressPorts(),
+			},
+		},
+	}
+}
+
+// alternateAddressSettings function returns alternate address from resource data
+func alternateAddressSettings(d *schema.ResourceData) alternateAddress {
+	ports := map[string]int{}
+	for name, port := range d.Get(keyAlternateAddressPorts).(map[string]interface{}) {
+		ports[name] = port.(int)
+	}
+
+	return alternateAddress{
+		Hostname: d.Get(keyAlternateAddressHostname).(string),
+		Ports:    ports,
+	}
+}
+
+func createAlternateAddress(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemConnection)
+
+	node := d.Get(keyAlternateAddressNode).(string)
+
+	if err := m.(*Connection).setAlternateAddress(c, node, alternateAddressSettings(d)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(node)
+
+	return readAlternateAddress(c, d, m)
+}
+
+func readAlternateAddress(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemConnection)
+
+	var diags diag.Diagnostics
+
+	address, err := m.(*Connection).getAlternateAddress(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if address == nil {
+		d.SetId("")
+		return diags
+	}
+
+	if err := d.Set(keyAlternateAddressNode, d.Id()); err != nil {
+		diags = append(diags, *diagForValueSet(keyAlternateAddressNode, d.Id(), err))
+	}
+
+	if err := d.Set(keyAlternateAddressHostname, address.Hostname); err != nil {
+		diags = append(diags, *diagForValueSet(keyAlternateAddressHostname, address.Hostname, err))
+	}
+
+	if err := d.Set(keyAlternateAddressPorts, address.Ports); err != nil {
+		diags = append(diags, *diagForValueSet(keyAlternateAddressPorts, address.Ports, err))
+	}
+
+	return diags
+}
+
+func updateAlternateAddress(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemConnection)
+
+	if d.HasChanges(keyAlternateAddressHostname, keyAlternateAddressPorts) {
+		if err := m.(*Connection).setAlternateAddress(c, d.Id(), alternateAddressSettings(d)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return readAlternateAddress(c, d, m)
+}
+
+func deleteAlternateAddress(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemConnection)
+
+	var diags diag.Diagnostics
+
+	if err := m.(*Connection).deleteAlternateAddress(c, d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
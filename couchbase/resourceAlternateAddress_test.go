@@ -0,0 +1,48 @@
+package couchbase
+
+import (
+	"testing"
+)
+
+// TestAlternateAddress function verify with fake cluster
+// - alternate address create, update and delete
+// - import of existing alternate address
+// - drift of alternate address changed or removed outside of terraform
+func TestAlternateAddress(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceAlternateAddress()
+
+	config := map[string]interface{}{
+		keyAlternateAddressNode:     "node1.example.com",
+		keyAlternateAddressHostname: "external1.example.com",
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{"id": "node1.example.com", keyAlternateAddressHostname: "external1.example.com"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyAlternateAddressPorts] = map[string]interface{}{"mgmt": 18091, "kv": 11210}
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"ports.mgmt": "18091", "ports.kv": "11210"})
+	if fake.alternateAddresses["node1.example.com"].Ports["mgmt"] != 18091 {
+		t.Fatalf("alternate address wasn't updated: %v", fake.alternateAddresses["node1.example.com"])
+	}
+
+	imported := testResourceImport(t, r, "node1.example.com", cc)
+	testCheckState(t, imported, map[string]string{keyAlternateAddressHostname: "external1.example.com", "ports.%": "2"})
+	testCheckNoDiff(t, r, imported, config, cc)
+
+	fake.alternateAddresses["node1.example.com"] = alternateAddress{Hostname: "changed.example.com"}
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{keyAlternateAddressHostname: "changed.example.com"})
+	testCheckDiff(t, r, state, config, cc)
+
+	delete(fake.alternateAddresses, "node1.example.com")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.alternateAddresses["node1.example.com"]; ok {
+		t.Fatalf("alternate address wasn't deleted")
+	}
+}
@@ -4,23 +4,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// bucketDeletionGuard contains deletion protection attributes of bucket
+var bucketDeletionGuard = deletionGuard{
+	resource:   "bucket",
+	protection: keyBucketDeletionProtection,
+	allowEmpty: keyBucketAllowDestroyIfEmpty,
+}
+
 func resourceBucket() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: createBucket,
 		ReadContext:   readBucket,
 		UpdateContext: updateBucket,
 		DeleteContext: deleteBucket,
 		Description:   "Manage buckets in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: bucketDeletionGuard.importState,
 		},
 		Schema: map[string]*schema.Schema{
 			keyBucketName: {
@@ -135,8 +151,41 @@ func resourceBucket() *schema.Resource {
 				),
 				ValidateDiagFunc: validateStorageBackend(),
 			},
+			keyBucketHistoryRetentionCollectionDefault: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "History retention enable/disable for new collections in bucket. Bucket must have \"magma\" storage backend",
+			},
+			keyBucketHistoryRetentionBytes: {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Computed:         true,
+				Description:      "Maximum size of history retained by each vBucket in bytes, 0 or at least 2 GiB. Bucket must have \"magma\" storage backend",
+				ValidateDiagFunc: validateHistoryRetentionBytes(),
+			},
+			keyBucketHistoryRetentionDuration: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Maximum duration of retained history in seconds. Bucket must have \"magma\" storage backend",
+			},
+			keyBucketAutoCompaction: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem:        autoCompactionStructure(),
+				Description: "Bucket auto compaction settings. Cluster auto compaction settings are used when it isn't set",
+			},
+			keyBucketDeletionProtection:  deletionProtectionSchema(),
+			keyBucketAllowDestroyIfEmpty: allowDestroyIfEmptySchema(),
 		},
 	}
+
+	// Schema with nested auto compaction block is built once and shared by diffs of all buckets
+	r.CustomizeDiff = customizeBucketDiff(r.Schema)
+
+	return r
 }
 
 // bucketSettings return bucket settings structure
@@ -152,9 +201,12 @@ func bucketSettings(
 	compressionMode string,
 	conflictResolutionType string,
 	durabilityLevel int,
-	storageBackend string) *gocb.CreateBucketSettings {
+	storageBackend string,
+	historyRetentionDefault gocb.HistoryRetentionCollectionDefault,
+	historyRetentionBytes int,
+	historyRetentionDuration int) *gocb.CreateBucketSettings {
 
-	return &gocb.CreateBucketSettings{
+	settings := &gocb.CreateBucketSettings{
 		BucketSettings: gocb.BucketSettings{
 			Name:                   name,
 			FlushEnabled:           flushEnabled,
@@ -170,9 +222,134 @@ func bucketSettings(
 		},
 		ConflictResolutionType: gocb.ConflictResolutionType(conflictResolutionType),
 	}
+
+	// History retention is sent only for magma buckets because server rejects it for other storage backends
+	if settings.StorageBackend == gocb.StorageBackendMagma {
+		settings.HistoryRetentionCollectionDefault = historyRetentionDefault
+		settings.HistoryRetentionBytes = uint64(historyRetentionBytes)
+		settings.HistoryRetentionDuration = time.Duration(historyRetentionDuration) * time.Second
+	}
+
+	return settings
+}
+
+// historyRetentionCollectionDefault function returns configured history retention default for collections.
+// Server default is kept when attribute isn't configured because boolean zero value can't be distinguished from false
+func historyRetentionCollectionDefault(config cty.Value) gocb.HistoryRetentionCollectionDefault {
+	value := configAttribute(config, keyBucketHistoryRetentionCollectionDefault)
+	if value.IsNull() || !value.IsKnown() {
+		return gocb.HistoryRetentionCollectionDefaultUnset
+	}
+
+	if value.True() {
+		return gocb.HistoryRetentionCollectionDefaultEnabled
+	}
+	return gocb.HistoryRetentionCollectionDefaultDisabled
+}
+
+// customizeBucketDiff function validates combination of bucket attributes which depend on bucket type, storage backend
+// and couchbase version. Replacement of protected bucket is rejected
+func customizeBucketDiff(attributes map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(c context.Context, d *schema.ResourceDiff, m interface{}) error {
+		cc := m.(*Connection)
+
+		if err := bucketDeletionGuard.customizeDiff(d, cc, attributes); err != nil {
+			return err
+		}
+
+		history := configuredAttributes(d.GetRawConfig(),
+			keyBucketHistoryRetentionCollectionDefault,
+			keyBucketHistoryRetentionBytes,
+			keyBucketHistoryRetentionDuration,
+		)
+
+		if d.Get(keyBucketStorageBackend).(string) != string(gocb.StorageBackendMagma) {
+			if len(history) > 0 {
+				return fmt.Errorf("%s requires %s %s", strings.Join(history, ", "), keyBucketStorageBackend, gocb.StorageBackendMagma)
+			}
+		} else {
+			if err := cc.requireClusterVersion(fmt.Sprintf("%s %s", keyBucketStorageBackend, gocb.StorageBackendMagma), versionMagma); err != nil {
+				return err
+			}
+
+			if len(history) > 0 {
+				if err := cc.requireClusterVersion(strings.Join(history, ", "), versionCollectionHistory); err != nil {
+					return err
+				}
+			}
+		}
+
+		bucketType := d.Get(keyBucketBucketType).(string)
+		if len(d.Get(keyBucketAutoCompaction).([]interface{})) > 0 && bucketType != string(gocb.CouchbaseBucketType) {
+			return fmt.Errorf("%s requires %s %s, %s bucket isn't stored on disk", keyBucketAutoCompaction, keyBucketBucketType, gocb.CouchbaseBucketType, bucketType)
+		}
+
+		if err := validateBucketCombination(gocb.BucketSettings{
+			Name:                   d.Get(keyBucketName).(string),
+			RAMQuotaMB:             uint64(d.Get(keyBucketQuota).(int)),
+			NumReplicas:            uint32(d.Get(keyBucketNumReplicas).(int)),
+			BucketType:             gocb.BucketType(bucketType),
+			EvictionPolicy:         gocb.EvictionPolicyType(d.Get(keyBucketEvictionPolicyType).(string)),
+			MinimumDurabilityLevel: gocb.DurabilityLevel(uint8(d.Get(keyBucketDurabilityLevel).(int))),
+			StorageBackend:         gocb.StorageBackend(d.Get(keyBucketStorageBackend).(string)),
+		}, cc.detectedClusterVersion()); err != nil {
+			return err
+		}
+
+		return validateBucketRAMQuota(c, d, cc)
+	}
+}
+
+// validateBucketRAMQuota function checks that ram quota of bucket fits into data service memory which isn't used by other
+// buckets. Current quota of existing bucket is available for resize. Check is skipped when quota doesn't grow or cluster
+// memory can't be read because server returns error during apply anyway
+func validateBucketRAMQuota(c context.Context, d *schema.ResourceDiff, cc *Connection) error {
+	c = cc.logContext(c, logSubsystemBucket)
+
+	if !d.NewValueKnown(keyBucketQuota) {
+		return nil
+	}
+
+	oldQuota, newQuota := d.GetChange(keyBucketQuota)
+	current := uint64(oldQuota.(int))
+	requested := uint64(newQuota.(int))
+	if requested <= current {
+		return nil
+	}
+
+	total, used, err := cc.getClusterRAMQuota(c)
+	if err != nil {
+		tflog.SubsystemWarn(c, logSubsystemBucket, "cannot check bucket ram quota", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	otherBuckets := used - min(current, used)
+	available := total - min(otherBuckets, total)
+
+	tflog.SubsystemDebug(c, logSubsystemBucket, "checking bucket ram quota", map[string]interface{}{
+		"requested":     requested,
+		"available":     available,
+		"total":         total,
+		"other_buckets": otherBuckets,
+	})
+
+	if requested > available {
+		return fmt.Errorf("%s exceeds cluster memory available for bucket: requested %d MiB, available %d MiB (data service quota %d MiB, used by other buckets %d MiB)",
+			keyBucketQuota, requested, available, total, otherBuckets)
+	}
+
+	return nil
 }
 
 func createBucket(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemBucket)
+
 	var diags diag.Diagnostics
 
 	bs := bucketSettings(
@@ -188,21 +365,32 @@ func createBucket(c context.Context, d *schema.ResourceData, m interface{}) diag
 		d.Get(keyBucketConflictResolutionType).(string),
 		d.Get(keyBucketDurabilityLevel).(int),
 		d.Get(keyBucketStorageBackend).(string),
+		historyRetentionCollectionDefault(d.GetRawConfig()),
+		d.Get(keyBucketHistoryRetentionBytes).(int),
+		d.Get(keyBucketHistoryRetentionDuration).(int),
 	)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	if err := couchbase.BucketManager.CreateBucket(*bs, nil); err != nil {
+	tflog.SubsystemDebug(c, logSubsystemBucket, "creating bucket", map[string]interface{}{
+		"name":            bs.Name,
+		"bucket_type":     bs.BucketType,
+		"ram_quota_mb":    bs.RAMQuotaMB,
+		"storage_backend": bs.StorageBackend,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.BucketManager.CreateBucket(*bs, &gocb.CreateBucketOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(bucketTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemBucket, "create bucket", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
-		_, err := couchbase.BucketManager.GetBucket(bs.Name, nil)
+		_, err := couchbase.BucketManager.GetBucket(bs.Name, &gocb.GetBucketOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
 		if err != nil && errors.Is(err, gocb.ErrBucketNotFound) {
 			return retry.RetryableError(err)
 		}
@@ -217,21 +405,33 @@ func createBucket(c context.Context, d *schema.ResourceData, m interface{}) diag
 		return diag.FromErr(err)
 	}
 
+	if autoCompaction := d.Get(keyBucketAutoCompaction).([]interface{}); len(autoCompaction) > 0 {
+		tflog.SubsystemDebug(c, logSubsystemBucket, "setting bucket auto compaction", map[string]interface{}{
+			"name": bs.Name,
+		})
+
+		// Management REST client repeats request with provider retry policy
+		if err := couchbase.setBucketAutoCompaction(c, bs.Name, autoCompaction); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return readBucket(c, d, m)
 }
 
 // nolint:gocyclo
-func readBucket(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readBucket(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemBucket)
+
 	var err error
 	bucketID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	bucket, err := couchbase.BucketManager.GetBucket(bucketID, nil)
+	bucket, err := couchbase.BucketManager.GetBucket(bucketID, &gocb.GetBucketOptions{Timeout: d.Timeout(schema.TimeoutRead), ParentSpan: requestSpan(c)})
 	if err != nil && errors.Is(err, gocb.ErrBucketNotFound) {
 		d.SetId("")
 		return diags
@@ -277,7 +477,7 @@ func readBucket(_ context.Context, d *schema.ResourceData, m interface{}) diag.D
 		diags = append(diags, *diagForValueSet(keyBucketCompressionMode, bucket.CompressionMode, err))
 	}
 
-	crt, err := m.(*Connection).getBucketConflictResolutionType(bucket.Name)
+	crt, err := couchbase.getBucketConflictResolutionType(c, bucket.Name)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -299,18 +499,46 @@ func readBucket(_ context.Context, d *schema.ResourceData, m interface{}) diag.D
 		diags = append(diags, *diagForValueSet(keyBucketStorageBackend, bucket.StorageBackend, err))
 	}
 
+	autoCompaction, purgeInterval, err := couchbase.getBucketAutoCompaction(c, bucket.Name)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary: fmt.Sprintf("cannot download couchbase data for %s \n",
+				keyBucketAutoCompaction),
+			Detail: fmt.Sprintf("error details: %s\n", err),
+		})
+	} else {
+		autoCompactionList := convertAutoCompactionToList(autoCompaction, purgeInterval)
+		if err = d.Set(keyBucketAutoCompaction, autoCompactionList); err != nil {
+			diags = append(diags, *diagForValueSet(keyBucketAutoCompaction, autoCompactionList, err))
+		}
+	}
+
+	historyRetentionDefault := bucket.HistoryRetentionCollectionDefault == gocb.HistoryRetentionCollectionDefaultEnabled
+	if err = d.Set(keyBucketHistoryRetentionCollectionDefault, historyRetentionDefault); err != nil {
+		diags = append(diags, *diagForValueSet(keyBucketHistoryRetentionCollectionDefault, historyRetentionDefault, err))
+	}
+
+	if err = d.Set(keyBucketHistoryRetentionBytes, bucket.HistoryRetentionBytes); err != nil {
+		diags = append(diags, *diagForValueSet(keyBucketHistoryRetentionBytes, bucket.HistoryRetentionBytes, err))
+	}
+
+	if err = d.Set(keyBucketHistoryRetentionDuration, bucket.HistoryRetentionDuration/time.Second); err != nil {
+		diags = append(diags, *diagForValueSet(keyBucketHistoryRetentionDuration, bucket.HistoryRetentionDuration/time.Second, err))
+	}
+
 	return diags
 }
 
 func updateBucket(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemBucket)
 
 	bucketID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	if d.HasChanges(
 		keyBucketName,
@@ -323,6 +551,9 @@ func updateBucket(c context.Context, d *schema.ResourceData, m interface{}) diag
 		keyBucketEvictionPolicyType,
 		keyBucketCompressionMode,
 		keyBucketDurabilityLevel,
+		keyBucketHistoryRetentionCollectionDefault,
+		keyBucketHistoryRetentionBytes,
+		keyBucketHistoryRetentionDuration,
 	) {
 
 		bs := bucketSettings(
@@ -338,9 +569,33 @@ func updateBucket(c context.Context, d *schema.ResourceData, m interface{}) diag
 			d.Get(keyBucketConflictResolutionType).(string),
 			d.Get(keyBucketDurabilityLevel).(int),
 			d.Get(keyBucketStorageBackend).(string),
+			historyRetentionCollectionDefault(d.GetRawConfig()),
+			d.Get(keyBucketHistoryRetentionBytes).(int),
+			d.Get(keyBucketHistoryRetentionDuration).(int),
 		)
 
-		if err := couchbase.BucketManager.UpdateBucket(bs.BucketSettings, nil); err != nil {
+		tflog.SubsystemDebug(c, logSubsystemBucket, "updating bucket", map[string]interface{}{
+			"name":         bs.Name,
+			"ram_quota_mb": bs.RAMQuotaMB,
+		})
+
+		if err := m.(*Connection).retryOperation(c, func() error {
+			return couchbase.BucketManager.UpdateBucket(bs.BucketSettings, &gocb.UpdateBucketOptions{Timeout: d.Timeout(schema.TimeoutUpdate), ParentSpan: requestSpan(c)})
+		}); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange(keyBucketAutoCompaction) {
+		autoCompaction := d.Get(keyBucketAutoCompaction).([]interface{})
+
+		tflog.SubsystemDebug(c, logSubsystemBucket, "setting bucket auto compaction", map[string]interface{}{
+			"name":            bucketID,
+			"cluster_default": len(autoCompaction) == 0,
+		})
+
+		// Management REST client repeats request with provider retry policy
+		if err := couchbase.setBucketAutoCompaction(c, bucketID, autoCompaction); err != nil {
 			return diag.FromErr(err)
 		}
 	}
@@ -348,18 +603,28 @@ func updateBucket(c context.Context, d *schema.ResourceData, m interface{}) diag
 	return readBucket(c, d, m)
 }
 
-func deleteBucket(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func deleteBucket(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemBucket)
 
 	bucketID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	if err := couchbase.BucketManager.DropBucket(bucketID, nil); err != nil {
-		diag.FromErr(err)
+	if err := bucketDeletionGuard.checkDelete(c, d, couchbase, bucketID); err != nil {
+		return diag.FromErr(err)
+	}
+
+	tflog.SubsystemDebug(c, logSubsystemBucket, "dropping bucket", map[string]interface{}{
+		"name": bucketID,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.BucketManager.DropBucket(bucketID, &gocb.DropBucketOptions{Timeout: d.Timeout(schema.TimeoutDelete), ParentSpan: requestSpan(c)})
+	}); err != nil {
+		return diag.FromErr(err)
 	}
 
 	d.SetId("")
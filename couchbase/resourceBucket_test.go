@@ -1,7 +1,10 @@
 package couchbase
 
 import (
+	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
@@ -65,3 +68,425 @@ func TestAccBucket(t *testing.T) {
 		},
 	})
 }
+
+// TestBucket function verify with fake cluster
+// - bucket create, update and delete
+// - import of existing bucket
+// - drift of bucket changed or removed outside of terraform
+func TestBucket(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceBucket()
+
+	config := map[string]interface{}{
+		keyBucketName:  "bucket",
+		keyBucketQuota: 100,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyBucketName:                   "bucket",
+		keyBucketQuota:                  "100",
+		keyBucketMaxExpiry:              "10",
+		keyBucketConflictResolutionType: "seqno",
+		keyBucketStorageBackend:         "couchstore",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyBucketQuota] = 200
+	config[keyBucketCompressionMode] = "active"
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketQuota: "200", keyBucketCompressionMode: "active"})
+	if fake.buckets["bucket"].RAMQuotaMB != 200 {
+		t.Fatalf("bucket wasn't updated: %v", fake.buckets["bucket"])
+	}
+
+	imported := testResourceImport(t, r, "bucket", cc)
+	testCheckState(t, imported, map[string]string{keyBucketName: "bucket", keyBucketQuota: "200"})
+	testCheckNoDiff(t, r, imported, config, cc)
+
+	bucket := fake.buckets["bucket"]
+	bucket.RAMQuotaMB = 300
+	fake.buckets["bucket"] = bucket
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{keyBucketQuota: "300"})
+	testCheckDiff(t, r, state, config, cc)
+
+	delete(fake.buckets, "bucket")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.buckets["bucket"]; ok {
+		t.Fatalf("bucket wasn't dropped")
+	}
+}
+
+const testServerBucket = `
+resource "couchbase_bucket_manager" "bucket" {
+    name                     = "testServerBucket_bucket_name"
+    ram_quota_mb             = 1024
+    conflict_resolution_type = "lww"
+    storage_backend          = "magma"
+}
+`
+
+// TestBucketCombination function verify with fake cluster that invalid combinations of bucket settings are
+// rejected during plan and valid combinations are planned
+func TestBucketCombination(t *testing.T) {
+	cc, _ := newFakeConnection()
+	cc.Version = clusterVersion{Major: 7, Minor: 6, Patch: 2}
+	r := resourceBucket()
+
+	cases := []struct {
+		config  map[string]interface{}
+		message string
+	}{
+		{map[string]interface{}{keyBucketBucketType: "memcached", keyBucketNumReplicas: 1}, "memcached bucket doesn't support replicas"},
+		{map[string]interface{}{keyBucketBucketType: "memcached", keyBucketDurabilityLevel: 2}, "memcached bucket doesn't support durability"},
+		{map[string]interface{}{keyBucketBucketType: "ephemeral"}, "ephemeral bucket doesn't support eviction_policy_type valueOnly"},
+		{map[string]interface{}{keyBucketBucketType: "ephemeral", keyBucketEvictionPolicyType: "fullEviction"}, "ephemeral bucket doesn't support eviction_policy_type fullEviction"},
+		{map[string]interface{}{keyBucketBucketType: "ephemeral", keyBucketEvictionPolicyType: "noEviction", keyBucketDurabilityLevel: 4}, "ephemeral bucket doesn't persist data"},
+		{map[string]interface{}{keyBucketEvictionPolicyType: "nruEviction"}, "membase bucket doesn't support eviction_policy_type nruEviction"},
+		{map[string]interface{}{keyBucketStorageBackend: "magma"}, "storage_backend magma requires ram_quota_mb 1024 or more"},
+		{map[string]interface{}{keyBucketBucketType: "ephemeral", keyBucketEvictionPolicyType: "noEviction", keyBucketStorageBackend: "magma", keyBucketQuota: 1024}, "storage_backend magma requires bucket_type membase"},
+		{map[string]interface{}{keyBucketNumReplicas: 3, keyBucketDurabilityLevel: 2}, "durability_level 2 isn't supported with 3 replicas"},
+		{map[string]interface{}{keyBucketBucketType: "memcached"}, ""},
+		{map[string]interface{}{keyBucketBucketType: "ephemeral", keyBucketEvictionPolicyType: "nruEviction", keyBucketDurabilityLevel: 2}, ""},
+		{map[string]interface{}{keyBucketStorageBackend: "magma", keyBucketQuota: 1024, keyBucketNumReplicas: 2, keyBucketDurabilityLevel: 4}, ""},
+	}
+
+	for _, tc := range cases {
+		config := map[string]interface{}{keyBucketName: "bucket", keyBucketQuota: 100}
+		for key, value := range tc.config {
+			config[key] = value
+		}
+
+		if tc.message != "" {
+			testCheckPlanError(t, r, nil, config, cc, tc.message)
+		} else {
+			testResourceDiff(t, r, nil, config, cc)
+		}
+	}
+
+	cc.Version = clusterVersion{Major: 8, Minor: 0}
+	testResourceDiff(t, r, nil, map[string]interface{}{keyBucketName: "bucket", keyBucketQuota: 100, keyBucketStorageBackend: "magma"}, cc)
+}
+
+// TestBucketRAMQuota function verify with fake cluster
+// - bucket ram quota is checked against memory which isn't used by other buckets during plan
+// - current quota of bucket is available for resize
+// - quota isn't checked when cluster memory is unknown
+func TestBucketRAMQuota(t *testing.T) {
+	cc, fake := newFakeConnection()
+	fake.ramQuota = 1024
+	r := resourceBucket()
+
+	config := map[string]interface{}{keyBucketName: "bucket", keyBucketQuota: 512}
+	state := testResourceApply(t, r, nil, config, cc)
+
+	testCheckPlanError(t, r, nil, map[string]interface{}{keyBucketName: "other", keyBucketQuota: 600}, cc,
+		"ram_quota_mb exceeds cluster memory available for bucket: requested 600 MiB, available 512 MiB (data service quota 1024 MiB, used by other buckets 512 MiB)")
+	testResourceDiff(t, r, nil, map[string]interface{}{keyBucketName: "other", keyBucketQuota: 512}, cc)
+
+	config[keyBucketQuota] = 1025
+	testCheckPlanError(t, r, state, config, cc, "requested 1025 MiB, available 1024 MiB")
+	config[keyBucketQuota] = 1024
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketQuota: "1024"})
+
+	fake.ramQuota = 0
+	testResourceDiff(t, r, nil, map[string]interface{}{keyBucketName: "other", keyBucketQuota: 600}, cc)
+}
+
+// TestBucketAutoCompaction function verify with fake cluster
+// - bucket auto compaction is set on create and update and read back without diff
+// - auto compaction changed outside of terraform is detected
+// - removed auto compaction block resets bucket to cluster auto compaction settings
+// - auto compaction is rejected during plan for bucket which isn't stored on disk
+func TestBucketAutoCompaction(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceBucket()
+
+	autoCompaction := map[string]interface{}{
+		keyAutoCompactionDatabaseFragmentationPercentage: 30,
+		keyAutoCompactionViewFragmentationSize:           104857600,
+		keyAutoCompactionParallelCompaction:              true,
+		keyAutoCompactionAllowedTimePeriod: []interface{}{
+			map[string]interface{}{
+				keyAutoCompactionFromHour:     1,
+				keyAutoCompactionToHour:       5,
+				keyAutoCompactionToMinute:     30,
+				keyAutoCompactionAbortOutside: true,
+			},
+		},
+	}
+	config := map[string]interface{}{
+		keyBucketName:           "bucket",
+		keyBucketQuota:          100,
+		keyBucketAutoCompaction: []interface{}{autoCompaction},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		"auto_compaction.#": "1",
+		"auto_compaction.0.database_fragmentation_threshold_percentage": "30",
+		"auto_compaction.0.view_fragmentation_threshold_size":           "104857600",
+		"auto_compaction.0.magma_fragmentation_percentage":              "50",
+		"auto_compaction.0.parallel_compaction":                         "true",
+		"auto_compaction.0.purge_interval":                              "3",
+		"auto_compaction.0.allowed_time_period.0.from_hour":             "1",
+		"auto_compaction.0.allowed_time_period.0.to_minute":             "30",
+		"auto_compaction.0.allowed_time_period.0.abort_outside":         "true",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+	if form := fake.autoCompaction["bucket"]; form.Get("allowedTimePeriod[toHour]") != "5" || form.Has("databaseFragmentationThreshold[size]") {
+		t.Fatalf("unexpected auto compaction parameters: %v", form)
+	}
+
+	autoCompaction[keyAutoCompactionDatabaseFragmentationPercentage] = 60
+	autoCompaction[keyAutoCompactionPurgeInterval] = 0.5
+	delete(autoCompaction, keyAutoCompactionAllowedTimePeriod)
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{
+		"auto_compaction.0.database_fragmentation_threshold_percentage": "60",
+		"auto_compaction.0.purge_interval":                              "0.5",
+		"auto_compaction.0.allowed_time_period.#":                       "0",
+	})
+
+	fake.autoCompaction["bucket"].Set("databaseFragmentationThreshold[percentage]", "80")
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{"auto_compaction.0.database_fragmentation_threshold_percentage": "80"})
+	testCheckDiff(t, r, state, config, cc)
+
+	delete(config, keyBucketAutoCompaction)
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"auto_compaction.#": "0"})
+	if form := fake.autoCompaction["bucket"]; form.Get("autoCompactionDefined") != "false" {
+		t.Fatalf("bucket wasn't reset to cluster auto compaction settings: %v", form)
+	}
+	testCheckNoDiff(t, r, state, config, cc)
+
+	testCheckPlanError(t, r, nil, map[string]interface{}{
+		keyBucketName:               "ephemeral",
+		keyBucketQuota:              100,
+		keyBucketBucketType:         "ephemeral",
+		keyBucketEvictionPolicyType: "noEviction",
+		keyBucketAutoCompaction:     []interface{}{map[string]interface{}{keyAutoCompactionDatabaseFragmentationPercentage: 30}},
+	}, cc, "auto_compaction requires bucket_type membase")
+}
+
+// TestBucketDeletionProtection function verify with fake cluster
+// - provider deletion protection is used when bucket doesn't configure it
+// - destroy and replacement of protected bucket are rejected
+// - protected bucket which allows destroy when it's empty is dropped only without items
+// - bucket without protection is dropped
+func TestBucketDeletionProtection(t *testing.T) {
+	cc, fake := newFakeConnection()
+	cc.DeletionProtection = true
+	r := resourceBucket()
+
+	config := map[string]interface{}{
+		keyBucketName:  "bucket",
+		keyBucketQuota: 100,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketDeletionProtection: "true", keyBucketAllowDestroyIfEmpty: "false"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	testCheckDestroyError(t, r, state, cc, "bucket bucket is protected by deletion_protection")
+	if _, ok := fake.buckets["bucket"]; !ok {
+		t.Fatalf("protected bucket was dropped")
+	}
+
+	config[keyBucketName] = "replaced"
+	testCheckPlanError(t, r, state, config, cc, "bucket bucket is protected by deletion_protection and cannot be replaced")
+	config[keyBucketName] = "bucket"
+
+	imported := testResourceImport(t, r, "bucket", cc)
+	testCheckState(t, imported, map[string]string{keyBucketDeletionProtection: "true", keyBucketAllowDestroyIfEmpty: "false"})
+
+	config[keyBucketAllowDestroyIfEmpty] = true
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketAllowDestroyIfEmpty: "true"})
+
+	fake.itemCount["bucket"] = 5
+	testCheckDestroyError(t, r, state, cc, "bucket bucket is protected by deletion_protection and isn't empty, bucket bucket contains 5 items")
+
+	fake.itemCount["bucket"] = 0
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.buckets["bucket"]; ok {
+		t.Fatalf("empty bucket wasn't dropped")
+	}
+
+	config = map[string]interface{}{
+		keyBucketName:               "bucket",
+		keyBucketQuota:              100,
+		keyBucketDeletionProtection: false,
+	}
+	state = testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketDeletionProtection: "false"})
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.buckets["bucket"]; ok {
+		t.Fatalf("bucket wasn't dropped")
+	}
+}
+
+// TestServerBucket function verify with fake couchbase server
+// - bucket create and import with terraform test steps
+func TestServerBucket(t *testing.T) {
+	testFakeServerPreCheck(t)
+	s := newFakeServer(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: s.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: s.providerConfig() + testServerBucket,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_bucket_manager.bucket", "name", "testServerBucket_bucket_name"),
+					resource.TestCheckResourceAttr("couchbase_bucket_manager.bucket", "conflict_resolution_type", "lww"),
+					resource.TestCheckResourceAttr("couchbase_bucket_manager.bucket", "storage_backend", "magma"),
+				),
+			},
+			{
+				ResourceName:      "couchbase_bucket_manager.bucket",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// TestServerBucketRetry function verify with fake couchbase server
+// - bucket create is repeated while cluster manager is unavailable
+// - conflict resolution type is read from cluster manager REST API
+// - bucket read fails when cluster manager doesn't respond within management timeout
+func TestServerBucketRetry(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+	r := resourceBucket()
+
+	config := map[string]interface{}{
+		keyBucketName:                   "bucket",
+		keyBucketQuota:                  100,
+		keyBucketConflictResolutionType: "lww",
+	}
+
+	s.inject(fakeServerFault{method: http.MethodPost, path: "/pools/default/buckets", status: http.StatusServiceUnavailable, count: 2})
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyBucketName: "bucket", keyBucketConflictResolutionType: "lww"})
+	if count := s.requestCount(http.MethodPost, "/pools/default/buckets"); count != 3 {
+		t.Fatalf("bucket create was sent %d times, expected 3", count)
+	}
+
+	s.inject(fakeServerFault{method: http.MethodGet, path: "/pools/default/buckets/bucket", delay: 100 * time.Millisecond, count: 2})
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckNoDiff(t, r, state, config, cc)
+
+	settings := s.settings()
+	settings[providerConnectionTimeout] = 1
+	cc = s.connection(t, settings)
+
+	s.inject(fakeServerFault{method: http.MethodGet, path: "/pools/default/buckets/bucket", delay: 2 * time.Second, after: 1, count: 1})
+	if _, diags := r.RefreshWithoutUpgrade(context.Background(), state, cc); !diags.HasError() {
+		t.Fatalf("bucket was read although cluster manager didn't respond")
+	}
+}
+
+// TestServerBucketHistoryRetention function verify with fake couchbase server
+// - magma bucket keeps server history retention defaults when attributes aren't configured
+// - history retention is updated and read back without diff
+// - history retention is rejected during plan for couchstore bucket and older cluster
+// - ram quota is checked against per node storage totals of cluster manager during plan
+func TestServerBucketHistoryRetention(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+	r := resourceBucket()
+
+	config := map[string]interface{}{
+		keyBucketName:           "bucket",
+		keyBucketQuota:          1024,
+		keyBucketStorageBackend: "magma",
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyBucketHistoryRetentionCollectionDefault: "true",
+		keyBucketHistoryRetentionBytes:             "0",
+		keyBucketHistoryRetentionDuration:          "0",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyBucketHistoryRetentionCollectionDefault] = false
+	config[keyBucketHistoryRetentionBytes] = 4294967296
+	config[keyBucketHistoryRetentionDuration] = 86400
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyBucketHistoryRetentionCollectionDefault: "false",
+		keyBucketHistoryRetentionBytes:             "4294967296",
+		keyBucketHistoryRetentionDuration:          "86400",
+	})
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckNoDiff(t, r, state, config, cc)
+
+	testCheckPlanError(t, r, nil, map[string]interface{}{
+		keyBucketName:                     "couchstore",
+		keyBucketQuota:                    100,
+		keyBucketHistoryRetentionDuration: 86400,
+	}, cc, "history_retention_duration requires storage_backend magma")
+
+	cc.Version = clusterVersion{Major: 7, Minor: 1}
+	testCheckPlanError(t, r, state, config, cc, "requires Couchbase 7.2+")
+
+	testCheckPlanError(t, r, nil, map[string]interface{}{keyBucketName: "other", keyBucketQuota: 3500}, cc,
+		"requested 3500 MiB, available 3072 MiB (data service quota 4096 MiB, used by other buckets 1024 MiB)")
+}
+
+// TestServerBucketAutoCompaction function verify with fake couchbase server
+// - auto compaction is sent to bucket REST API and undefined thresholds are read back without diff
+// - removed auto compaction block resets bucket to cluster auto compaction settings
+// - failed auto compaction request is repeated only by retry policy of management REST client
+func TestServerBucketAutoCompaction(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+	r := resourceBucket()
+
+	config := map[string]interface{}{
+		keyBucketName:  "bucket",
+		keyBucketQuota: 100,
+		keyBucketAutoCompaction: []interface{}{
+			map[string]interface{}{
+				keyAutoCompactionViewFragmentationPercentage: 40,
+				keyAutoCompactionAllowedTimePeriod: []interface{}{
+					map[string]interface{}{keyAutoCompactionFromHour: 22, keyAutoCompactionToHour: 4},
+				},
+			},
+		},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		"auto_compaction.0.view_fragmentation_threshold_percentage":     "40",
+		"auto_compaction.0.database_fragmentation_threshold_percentage": "0",
+		"auto_compaction.0.allowed_time_period.0.from_hour":             "22",
+	})
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckNoDiff(t, r, state, config, cc)
+
+	autoCompaction := config[keyBucketAutoCompaction]
+	delete(config, keyBucketAutoCompaction)
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"auto_compaction.#": "0"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyBucketAutoCompaction] = autoCompaction
+	requests := s.requestCount(http.MethodPost, "/pools/default/buckets/bucket")
+	s.inject(fakeServerFault{method: http.MethodPost, path: "/pools/default/buckets/bucket", status: http.StatusServiceUnavailable})
+	if _, diags := r.Apply(context.Background(), state, testResourceDiff(t, r, state, config, cc), cc); !diags.HasError() {
+		t.Fatalf("auto compaction was set while cluster manager is unavailable")
+	}
+	if count := s.requestCount(http.MethodPost, "/pools/default/buckets/bucket") - requests; count != 5 {
+		t.Fatalf("auto compaction request was sent %d times, expected 5 attempts of retry policy", count)
+	}
+}
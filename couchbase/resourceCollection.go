@@ -8,19 +8,34 @@ import (
 	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// collectionDeletionGuard contains deletion protection attributes of collection
+var collectionDeletionGuard = deletionGuard{
+	resource:   "collection",
+	protection: keyCollectionDeletionProtection,
+	allowEmpty: keyCollectionAllowDestroyIfEmpty,
+}
+
 func resourceCollection() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: createCollection,
 		ReadContext:   readCollection,
+		UpdateContext: updateCollection,
 		DeleteContext: deleteCollection,
 		Description:   "Manage collections in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: collectionDeletionGuard.importState,
 		},
 		Schema: map[string]*schema.Schema{
 			keyCollectionBucketName: {
@@ -55,8 +70,14 @@ func resourceCollection() *schema.Resource {
 				Optional:    true,
 				Description: "Collection history enable/disable. Bucket must have \"magma\" storage mode",
 			},
+			keyCollectionDeletionProtection:  deletionProtectionSchema(),
+			keyCollectionAllowDestroyIfEmpty: allowDestroyIfEmptySchema(),
 		},
 	}
+
+	r.CustomizeDiff = customizeCollectionDiff(r.Schema)
+
+	return r
 }
 
 // collectionSettings return settings structure for collection resource
@@ -78,19 +99,40 @@ func collectionSettings(
 	}
 }
 
+// customizeCollectionDiff function validates collection attributes which depend on couchbase version and rejects
+// replacement of protected collection
+func customizeCollectionDiff(attributes map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+		cc := m.(*Connection)
+
+		if err := cc.requireClusterVersion("collection", versionCollections); err != nil {
+			return err
+		}
+
+		if d.Get(keyCollectionHistory).(bool) {
+			if err := cc.requireClusterVersion(keyCollectionHistory, versionCollectionHistory); err != nil {
+				return err
+			}
+		}
+
+		return collectionDeletionGuard.customizeDiff(d, cc, attributes)
+	}
+}
+
 func createCollection(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 
 	bucketName := d.Get(keyCollectionBucketName).(string)
 	historyInput := d.Get(keyCollectionHistory).(bool)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	history, err := couchbase.getCollectionHistorySettings(bucketName, historyInput)
+	history, err := couchbase.getCollectionHistorySettings(bucketName, historyInput, d.Timeout(schema.TimeoutCreate))
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -103,16 +145,24 @@ func createCollection(c context.Context, d *schema.ResourceData, m interface{})
 		history,
 	)
 
-	cm := couchbase.Cluster.Bucket(cs.Bucket).CollectionsV2()
+	cm := couchbase.Cluster.Collections(cs.Bucket)
 
-	if err := cm.CreateCollection(cs.Scope, cs.Name, cs.Settings, nil); err != nil {
+	tflog.SubsystemDebug(c, logSubsystemCollection, "creating collection", map[string]interface{}{
+		"bucket": bucketName,
+		"scope":  cs.Scope,
+		"name":   cs.Name,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return cm.CreateCollection(cs.Scope, cs.Name, cs.Settings, &gocb.CreateCollectionOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(collectionTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemCollection, "create collection", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
 		target := &ErrCollectionNotFound{}
-		_, err := findCollection(cm, cs.Name, cs.Scope)
+		_, err := findCollection(cm, cs.Name, cs.Scope, d.Timeout(schema.TimeoutCreate))
 		if errors.As(err, &target) {
 			return retry.RetryableError(target)
 		}
@@ -130,7 +180,9 @@ func createCollection(c context.Context, d *schema.ResourceData, m interface{})
 	return readCollection(c, d, m)
 }
 
-func readCollection(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readCollection(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 	names := strings.Split(d.Id(), "/")
 	if len(names) != 3 {
@@ -141,17 +193,22 @@ func readCollection(_ context.Context, d *schema.ResourceData, m interface{}) di
 	scopeName := names[1]
 	collectionName := names[2]
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	cm := couchbase.Cluster.Bucket(bucketName).CollectionsV2()
+	cm := couchbase.Cluster.Collections(bucketName)
 
-	collection, err := findCollection(cm, collectionName, scopeName)
-	if err != nil {
+	collection, err := findCollection(cm, collectionName, scopeName, d.Timeout(schema.TimeoutRead))
+	scopeNotFound := &ErrScopeNotFound{}
+	collectionNotFound := &ErrCollectionNotFound{}
+	if errors.As(err, &scopeNotFound) || errors.As(err, &collectionNotFound) || errors.Is(err, gocb.ErrBucketNotFound) {
 		d.SetId("")
+		return diags
+	}
+
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -168,23 +225,41 @@ func readCollection(_ context.Context, d *schema.ResourceData, m interface{}) di
 	return diags
 }
 
-func deleteCollection(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+// updateCollection function updates only deletion protection which is stored in terraform state
+func updateCollection(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return readCollection(c, d, m)
+}
+
+func deleteCollection(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	names := strings.Split(d.Id(), "/")
 	bucketName := names[0]
 	scopeName := names[1]
 	collectionName := names[2]
 
-	cm := couchbase.Cluster.Bucket(bucketName).CollectionsV2()
+	if err := collectionDeletionGuard.checkDelete(c, d, couchbase, bucketName); err != nil {
+		return diag.FromErr(err)
+	}
 
-	if err := cm.DropCollection(scopeName, collectionName, nil); err != nil {
+	cm := couchbase.Cluster.Collections(bucketName)
+
+	tflog.SubsystemDebug(c, logSubsystemCollection, "dropping collection", map[string]interface{}{
+		"bucket": bucketName,
+		"scope":  scopeName,
+		"name":   collectionName,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return cm.DropCollection(scopeName, collectionName, &gocb.DropCollectionOptions{Timeout: d.Timeout(schema.TimeoutDelete), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
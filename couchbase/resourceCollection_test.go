@@ -2,7 +2,9 @@ package couchbase
 
 import (
 	"testing"
+	"time"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -89,3 +91,109 @@ func TestAccCollectionBucketMagmaStorage(t *testing.T) {
 		},
 	})
 }
+
+// TestCollection function verify with fake cluster
+// - collection create and delete
+// - import of existing collection
+// - max_expire changed outside of terraform doesn't plan replacement of collection
+// - drift of collection removed outside of terraform
+func TestCollection(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceCollection()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Collections("bucket").CreateScope("scope", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyCollectionBucketName: "bucket",
+		keyCollectionScopeName:  "scope",
+		keyCollectionName:       "collection",
+		keyCollectionMaxExpiry:  60,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		"id":                   "bucket/scope/collection",
+		keyCollectionName:      "collection",
+		keyCollectionMaxExpiry: "60",
+		keyCollectionHistory:   "false",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	imported := testResourceImport(t, r, "bucket/scope/collection", cc)
+	testCheckState(t, imported, map[string]string{
+		"id":                    "bucket/scope/collection",
+		keyCollectionName:       "collection",
+		keyCollectionBucketName: "bucket",
+		keyCollectionScopeName:  "scope",
+	})
+
+	collection := fake.collections["bucket"]["scope"]["collection"]
+	collection.MaxExpiry = 120 * time.Second
+	fake.collections["bucket"]["scope"]["collection"] = collection
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{keyCollectionMaxExpiry: "60"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	delete(fake.collections["bucket"]["scope"], "collection")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	delete(fake.collections["bucket"], "scope")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	if err := fake.Collections("bucket").CreateScope("scope", nil); err != nil {
+		t.Fatal(err)
+	}
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.collections["bucket"]["scope"]["collection"]; ok {
+		t.Fatalf("collection wasn't dropped")
+	}
+}
+
+// TestCollectionDeletionProtection function verify with fake cluster
+// - replacement of protected collection which allows destroy when it's empty is planned
+// - protected collection is dropped only when bucket doesn't contain any items
+func TestCollectionDeletionProtection(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceCollection()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Collections("bucket").CreateScope("scope", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyCollectionBucketName:          "bucket",
+		keyCollectionScopeName:           "scope",
+		keyCollectionName:                "collection",
+		keyCollectionDeletionProtection:  true,
+		keyCollectionAllowDestroyIfEmpty: true,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyCollectionDeletionProtection: "true", keyCollectionAllowDestroyIfEmpty: "true"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyCollectionName] = "replaced"
+	testCheckDiff(t, r, state, config, cc)
+
+	fake.itemCount["bucket"] = 1
+	testCheckDestroyError(t, r, state, cc, "collection bucket/scope/collection is protected by deletion_protection and isn't empty")
+	if _, ok := fake.collections["bucket"]["scope"]["collection"]; !ok {
+		t.Fatalf("protected collection was dropped")
+	}
+
+	fake.itemCount["bucket"] = 0
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.collections["bucket"]["scope"]["collection"]; ok {
+		t.Fatalf("collection wasn't dropped")
+	}
+}
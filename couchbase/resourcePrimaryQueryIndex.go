@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/couchbase/gocb/v2"
@@ -13,11 +14,18 @@ import (
 )
 
 func resourcePrimaryQueryIndex() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: createPrimaryQueryIndex,
 		ReadContext:   readPrimaryQueryIndex,
+		UpdateContext: updatePrimaryQueryIndex,
 		DeleteContext: deletePrimaryQueryIndex,
 		Description:   "Manage primary query indexes in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: importQueryIndex,
 		},
@@ -34,67 +42,172 @@ func resourcePrimaryQueryIndex() *schema.Resource {
 				ForceNew:    true,
 				Description: "Primary query index bucket name",
 			},
+			keyPrimaryQueryIndexScope: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{keyPrimaryQueryIndexCollection},
+				Description:  "Primary query index scope name. Index is created in bucket default collection when scope isn't set",
+			},
+			keyPrimaryQueryIndexCollection: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{keyPrimaryQueryIndexScope},
+				Description:  "Primary query index collection name. Index is created in bucket default collection when collection isn't set",
+			},
 			keyPrimaryQueryIndexNumReplica: {
 				Type:        schema.TypeInt,
 				Required:    false,
 				Optional:    true,
 				Default:     0,
-				ForceNew:    true,
+				ForceNew:    false,
 				Description: "Primary query index number of replica",
 			},
+			keyPrimaryQueryIndexNodes: {
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Required:    false,
+				Optional:    true,
+				Computed:    true,
+				Description: "Primary query index placement nodes with index service (hostname:port e.g. 10.0.0.1:8091). Nodes selected by indexer are used when value isn't set",
+			},
+			keyPrimaryQueryIndexNumPartition: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Primary query index number of partitions",
+			},
+			keyPrimaryQueryIndexStatus: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Primary query index status reported by indexer",
+			},
+			keyPrimaryQueryIndexHosts: {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Computed:    true,
+				Description: "Nodes where primary query index and its replicas are placed",
+			},
+			keyPrimaryQueryIndexProgress: {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Primary query index build progress in percent",
+			},
+			keyPrimaryQueryIndexDeferred: {
+				Type:        schema.TypeBool,
+				Required:    false,
+				Optional:    true,
+				Default:     true,
+				ForceNew:    true,
+				Description: "Create primary query index in deferred state. Deferred index must be built (e.g. with couchbase_query_index_build resource)",
+			},
 		},
 	}
+
+	r.CustomizeDiff = customizePrimaryQueryIndexDiff(r.Schema)
+
+	return r
+}
+
+// customizePrimaryQueryIndexDiff function forces replacement when index isn't placed on new nodes and validates
+// that couchbase version supports scope, collection and change of replica count.
+// Replica count of replaced index isn't validated because index is created with new replica count
+func customizePrimaryQueryIndexDiff(attributes map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+		replace, err := customizeQueryIndexNodes(d, keyPrimaryQueryIndexNodes, keyPrimaryQueryIndexHosts)
+		if err != nil {
+			return err
+		}
+
+		if keyspace := configuredAttributes(d.GetRawConfig(), keyPrimaryQueryIndexScope, keyPrimaryQueryIndexCollection); len(keyspace) > 0 {
+			if err := m.(*Connection).requireClusterVersion(strings.Join(keyspace, ", "), versionCollections); err != nil {
+				return err
+			}
+		}
+
+		if d.Id() != "" && d.HasChange(keyPrimaryQueryIndexNumReplica) && !replace && !requiresReplacement(d, attributes) {
+			return m.(*Connection).requireClusterVersion(fmt.Sprintf("%s update", keyPrimaryQueryIndexNumReplica), versionAlterIndexReplica)
+		}
+
+		return nil
+	}
 }
 
 func createPrimaryQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	const deferred = true
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	indexName := d.Get(keyPrimaryQueryIndexName).(string)
 	bucketName := d.Get(keyPrimaryQueryIndexBucket).(string)
+	scopeName := d.Get(keyPrimaryQueryIndexScope).(string)
+	collectionName := d.Get(keyPrimaryQueryIndexCollection).(string)
+	keyspace := getKeyspace(bucketName, scopeName, collectionName)
 	numReplica := d.Get(keyPrimaryQueryIndexNumReplica).(int)
+	deferred := d.Get(keyPrimaryQueryIndexDeferred).(bool)
+	nodes, err := convertFieldsToList(d.Get(keyPrimaryQueryIndexNodes).(*schema.Set).List())
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	if err := couchbase.createPrimaryQueryIndex(indexName, bucketName, deferred, numReplica); err != nil {
+	if err := m.(*Connection).validateQueryIndexNodes(c, nodes); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(queryIndexTimeoutCreate)*time.Second, func() *retry.RetryError {
+	with := queryIndexWith{
+		DeferBuild: deferred,
+		NumReplica: numReplica,
+		Nodes:      nodes,
+	}
+
+	cc := m.(*Connection)
 
-		idx, err := couchbase.readQueryIndexByName(indexName, bucketName)
+	if err := cc.queryIndexDDL(c, d.Timeout(schema.TimeoutCreate), func() error {
+		return couchbase.QueryIndexManager.createPrimaryQueryIndex(c, indexName, keyspace, with, d.Timeout(schema.TimeoutCreate))
+	}); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := waitFor(c, logSubsystemIndex, "create primary query index", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
+
+		idx, err := couchbase.QueryIndexManager.readQueryIndexByName(c, indexName, bucketName, scopeName, collectionName, d.Timeout(schema.TimeoutCreate))
 		if err != nil {
 			return retry.RetryableError(err)
 		}
 
 		if idx.IsPrimary && idx.Name == indexName {
 			if idx.State != getDeferredState(deferred) {
-				return retry.RetryableError(fmt.Errorf("primary query index: %s bucket: %s creation in progress: %s", indexName, bucketName, idx.State))
+				return retry.RetryableError(fmt.Errorf("primary query index: %s keyspace: %s creation in progress: %s", indexName, keyspace, idx.State))
 			}
 			d.SetId(idx.ID)
 			return nil
 		}
 
-		return retry.NonRetryableError(fmt.Errorf("primary query index doesn't exist index: %s bucket: %s", indexName, bucketName))
+		return retry.NonRetryableError(fmt.Errorf("primary query index doesn't exist index: %s keyspace: %s", indexName, keyspace))
 	}); err != nil {
 		return diag.FromErr(err)
 	}
+	cc.invalidateIndexStatus()
 
 	return readPrimaryQueryIndex(c, d, m)
 }
 
-func readPrimaryQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readPrimaryQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	idx, err := couchbase.readQueryIndexByID(d.Id())
+	idx, err := couchbase.QueryIndexManager.readQueryIndexByID(c, d.Id(), d.Timeout(schema.TimeoutRead))
 	if err != nil && errors.Is(err, gocb.ErrIndexNotFound) {
 		d.SetId("")
 		return diags
@@ -111,30 +224,71 @@ func readPrimaryQueryIndex(_ context.Context, d *schema.ResourceData, m interfac
 	if err := d.Set(keyPrimaryQueryIndexName, idx.Name); err != nil {
 		diags = append(diags, *diagForValueSet(keyPrimaryQueryIndexName, idx.Name, err))
 	}
-	if err := d.Set(keyPrimaryQueryIndexBucket, idx.KeyspaceID); err != nil {
-		diags = append(diags, *diagForValueSet(keyPrimaryQueryIndexBucket, idx.KeyspaceID, err))
-	}
+	diags = append(diags, setQueryIndexKeyspace(d, idx, keyPrimaryQueryIndexBucket, keyPrimaryQueryIndexScope, keyPrimaryQueryIndexCollection)...)
+	diags = append(diags, m.(*Connection).setQueryIndexStatus(c, d, idx.ID, queryIndexStatusKeys{
+		NumReplica:   keyPrimaryQueryIndexNumReplica,
+		NumPartition: keyPrimaryQueryIndexNumPartition,
+		Status:       keyPrimaryQueryIndexStatus,
+		Hosts:        keyPrimaryQueryIndexHosts,
+		Progress:     keyPrimaryQueryIndexProgress,
+	})...)
 
 	return diags
 }
 
-func deletePrimaryQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func updatePrimaryQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
+
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
+	if diags != nil {
+		return diags
+	}
+
+	if d.HasChange(keyPrimaryQueryIndexNumReplica) {
+		indexName := d.Get(keyPrimaryQueryIndexName).(string)
+		numReplica := d.Get(keyPrimaryQueryIndexNumReplica).(int)
+		nodes, err := convertFieldsToList(d.Get(keyPrimaryQueryIndexNodes).(*schema.Set).List())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		keyspace := getKeyspace(
+			d.Get(keyPrimaryQueryIndexBucket).(string),
+			d.Get(keyPrimaryQueryIndexScope).(string),
+			d.Get(keyPrimaryQueryIndexCollection).(string),
+		)
+
+		cc := m.(*Connection)
+
+		if err := cc.alterQueryIndexReplicas(c, couchbase.QueryIndexManager, d.Id(), indexName, keyspace, numReplica, nodes, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := cc.waitQueryIndexReplicas(c, d.Id(), numReplica, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return readPrimaryQueryIndex(c, d, m)
+}
+
+func deletePrimaryQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	indexName := d.Get(keyPrimaryQueryIndexName).(string)
 	bucketName := d.Get(keyPrimaryQueryIndexBucket).(string)
+	scopeName := d.Get(keyPrimaryQueryIndexScope).(string)
+	collectionName := d.Get(keyPrimaryQueryIndexCollection).(string)
 
-	qis := gocb.DropPrimaryQueryIndexOptions{
-		IgnoreIfNotExists: true,
-		CustomName:        indexName,
-	}
+	cc := m.(*Connection)
 
-	if err := couchbase.QueryIndexManager.DropPrimaryIndex(bucketName, &qis); err != nil {
+	if err := cc.queryIndexDDL(c, d.Timeout(schema.TimeoutDelete), func() error {
+		return couchbase.QueryIndexManager.dropPrimaryQueryIndex(c, indexName, bucketName, scopeName, collectionName, d.Timeout(schema.TimeoutDelete))
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -3,6 +3,7 @@ package couchbase
 import (
 	"testing"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -42,8 +43,60 @@ func TestAccPrimaryQueryIndex(t *testing.T) {
 					resource.TestCheckResourceAttr("couchbase_bucket_manager.bucket", "num_replicas", "0"),
 					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "name", "testAccPrimaryQueryIndex_basic_primary_index_name"),
 					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "bucket", "testAccPrimaryQueryIndex_basic_bucket_name"),
+					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "hosts.#", "1"),
 				),
 			},
 		},
 	})
 }
+
+// TestPrimaryQueryIndex function verify with fake cluster
+// - primary query index create, replica update and delete
+// - import of existing primary query index by ID and by name
+// - drift of primary query index removed outside of terraform
+func TestPrimaryQueryIndex(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourcePrimaryQueryIndex()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyPrimaryQueryIndexName:     "primary",
+		keyPrimaryQueryIndexBucket:   "bucket",
+		keyPrimaryQueryIndexDeferred: false,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyPrimaryQueryIndexName:       "primary",
+		keyPrimaryQueryIndexBucket:     "bucket",
+		keyPrimaryQueryIndexNumReplica: "0",
+		keyPrimaryQueryIndexStatus:     "Ready",
+		"hosts.#":                      "1",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyPrimaryQueryIndexNumReplica] = 1
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keyPrimaryQueryIndexNumReplica: "1", "hosts.#": "2"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	imported := testResourceImport(t, r, state.ID, cc)
+	testCheckState(t, imported, map[string]string{keyPrimaryQueryIndexName: "primary", keyPrimaryQueryIndexNumReplica: "1"})
+
+	imported = testResourceImport(t, r, "bucket/primary", cc)
+	if imported.ID != state.ID {
+		t.Fatalf("imported index id: %s, expected: %s", imported.ID, state.ID)
+	}
+
+	delete(fake.indexes, state.ID)
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.indexes[state.ID]; ok {
+		t.Fatalf("primary query index wasn't dropped")
+	}
+}
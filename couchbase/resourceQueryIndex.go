@@ -4,20 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceQueryIndex() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: createQueryIndex,
 		ReadContext:   readQueryIndex,
+		UpdateContext: updateQueryIndex,
 		DeleteContext: deleteQueryIndex,
 		Description:   "Manage query indexes in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: importQueryIndex,
 		},
@@ -34,6 +43,20 @@ func resourceQueryIndex() *schema.Resource {
 				ForceNew:    true,
 				Description: "Query index bucket name",
 			},
+			keyQueryIndexScope: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{keyQueryIndexCollection},
+				Description:  "Query index scope name. Index is created in bucket default collection when scope isn't set",
+			},
+			keyQueryIndexCollection: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{keyQueryIndexScope},
+				Description:  "Query index collection name. Index is created in bucket default collection when collection isn't set",
+			},
 			keyQueryIndexFields: {
 				Type: schema.TypeList,
 				Elem: &schema.Schema{
@@ -44,6 +67,27 @@ func resourceQueryIndex() *schema.Resource {
 				ForceNew:    true,
 				Description: "Query index fields",
 			},
+			keyQueryIndexPartitionBy: {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Required:         false,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressQueryIndexExpressionDiff,
+				Description:      "Query index partition expressions used in PARTITION BY HASH clause",
+			},
+			keyQueryIndexNumPartition: {
+				Type:             schema.TypeInt,
+				Required:         false,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				RequiredWith:     []string{keyQueryIndexPartitionBy},
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				Description:      "Query index number of partitions. Couchbase default is used when value isn't set. Value is reported by indexer",
+			},
 			keyQueryIndexCondition: {
 				Type:        schema.TypeString,
 				Required:    false,
@@ -57,72 +101,164 @@ func resourceQueryIndex() *schema.Resource {
 				Required:    false,
 				Optional:    true,
 				Default:     0,
-				ForceNew:    true,
+				ForceNew:    false,
 				Description: "Query index number of replica",
 			},
+			keyQueryIndexNodes: {
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Required:    false,
+				Optional:    true,
+				Computed:    true,
+				Description: "Query index placement nodes with index service (hostname:port e.g. 10.0.0.1:8091). Nodes selected by indexer are used when value isn't set",
+			},
+			keyQueryIndexStatus: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Query index status reported by indexer",
+			},
+			keyQueryIndexHosts: {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Computed:    true,
+				Description: "Nodes where query index and its replicas are placed",
+			},
+			keyQueryIndexProgress: {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Query index build progress in percent",
+			},
+			keyQueryIndexDeferred: {
+				Type:        schema.TypeBool,
+				Required:    false,
+				Optional:    true,
+				Default:     true,
+				ForceNew:    true,
+				Description: "Create query index in deferred state. Deferred index must be built (e.g. with couchbase_query_index_build resource)",
+			},
 		},
 	}
+
+	r.CustomizeDiff = customizeQueryIndexDiff(r.Schema)
+
+	return r
+}
+
+// customizeQueryIndexDiff function forces replacement when index isn't placed on new nodes and validates that
+// couchbase version supports scope, collection and change of replica count.
+// Replica count of replaced index isn't validated because index is created with new replica count
+func customizeQueryIndexDiff(attributes map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+		replace, err := customizeQueryIndexNodes(d, keyQueryIndexNodes, keyQueryIndexHosts)
+		if err != nil {
+			return err
+		}
+
+		if keyspace := configuredAttributes(d.GetRawConfig(), keyQueryIndexScope, keyQueryIndexCollection); len(keyspace) > 0 {
+			if err := m.(*Connection).requireClusterVersion(strings.Join(keyspace, ", "), versionCollections); err != nil {
+				return err
+			}
+		}
+
+		if d.Id() != "" && d.HasChange(keyQueryIndexNumReplica) && !replace && !requiresReplacement(d, attributes) {
+			return m.(*Connection).requireClusterVersion(fmt.Sprintf("%s update", keyQueryIndexNumReplica), versionAlterIndexReplica)
+		}
+
+		return nil
+	}
 }
 
 func createQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	const deferred = true
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	indexName := d.Get(keyQueryIndexName).(string)
 	bucketName := d.Get(keyQueryIndexBucket).(string)
+	scopeName := d.Get(keyQueryIndexScope).(string)
+	collectionName := d.Get(keyQueryIndexCollection).(string)
+	keyspace := getKeyspace(bucketName, scopeName, collectionName)
 	numReplica := d.Get(keyQueryIndexNumReplica).(int)
 	condition := d.Get(keyQueryIndexCondition).(string)
+	deferred := d.Get(keyQueryIndexDeferred).(bool)
 	fields, err := convertFieldsToList(d.Get(keyQueryIndexFields).([]interface{}))
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	partitionBy, err := convertFieldsToList(d.Get(keyQueryIndexPartitionBy).([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	nodes, err := convertFieldsToList(d.Get(keyQueryIndexNodes).(*schema.Set).List())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := m.(*Connection).validateQueryIndexNodes(c, nodes); err != nil {
+		return diag.FromErr(err)
+	}
 
-	err = couchbase.Cluster.Bucket(bucketName).WaitUntilReady(time.Duration(queryIndexTimeoutCreate)*time.Second, &gocb.WaitUntilReadyOptions{DesiredState: gocb.ClusterStateOnline})
+	with := queryIndexWith{
+		DeferBuild:   deferred,
+		NumReplica:   numReplica,
+		NumPartition: d.Get(keyQueryIndexNumPartition).(int),
+		Nodes:        nodes,
+	}
+
+	err = couchbase.Cluster.WaitUntilBucketReady(bucketName, d.Timeout(schema.TimeoutCreate))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := couchbase.createQueryIndex(indexName, bucketName, fields, condition, deferred, numReplica); err != nil {
+	cc := m.(*Connection)
+
+	if err := cc.queryIndexDDL(c, d.Timeout(schema.TimeoutCreate), func() error {
+		return couchbase.QueryIndexManager.createQueryIndex(c, indexName, keyspace, fields, partitionBy, condition, with, d.Timeout(schema.TimeoutCreate))
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(queryIndexTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemIndex, "create query index", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
-		idx, err := couchbase.readQueryIndexByName(indexName, bucketName)
+		idx, err := couchbase.QueryIndexManager.readQueryIndexByName(c, indexName, bucketName, scopeName, collectionName, d.Timeout(schema.TimeoutCreate))
 		if err != nil {
 			return retry.RetryableError(err)
 		}
 
 		if !idx.IsPrimary && idx.Name == indexName {
 			if idx.State != getDeferredState(deferred) {
-				return retry.RetryableError(fmt.Errorf("primary query index: %s bucket: %s creation in progress: %s", indexName, bucketName, idx.State))
+				return retry.RetryableError(fmt.Errorf("query index: %s keyspace: %s creation in progress: %s", indexName, keyspace, idx.State))
 			}
 			d.SetId(idx.ID)
 			return nil
 		}
 
-		return retry.NonRetryableError(fmt.Errorf("query index doesn't exist index: %s bucket: %s", indexName, bucketName))
+		return retry.NonRetryableError(fmt.Errorf("query index doesn't exist index: %s keyspace: %s", indexName, keyspace))
 	}); err != nil {
 		return diag.FromErr(err)
 	}
+	cc.invalidateIndexStatus()
 
 	return readQueryIndex(c, d, m)
 }
 
-func readQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	idx, err := couchbase.readQueryIndexByID(d.Id())
+	idx, err := couchbase.QueryIndexManager.readQueryIndexByID(c, d.Id(), d.Timeout(schema.TimeoutRead))
 	if err != nil && errors.Is(err, gocb.ErrIndexNotFound) {
 		d.SetId("")
 		return diags
@@ -139,9 +275,7 @@ func readQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) di
 	if err := d.Set(keyPrimaryQueryIndexName, idx.Name); err != nil {
 		diags = append(diags, *diagForValueSet(keyPrimaryQueryIndexName, idx.Name, err))
 	}
-	if err := d.Set(keyPrimaryQueryIndexBucket, idx.KeyspaceID); err != nil {
-		diags = append(diags, *diagForValueSet(keyPrimaryQueryIndexBucket, idx.KeyspaceID, err))
-	}
+	diags = append(diags, setQueryIndexKeyspace(d, idx, keyQueryIndexBucket, keyQueryIndexScope, keyQueryIndexCollection)...)
 	if err := d.Set(keyQueryIndexCondition, idx.Condition); err != nil {
 		diags = append(diags, *diagForValueSet(keyQueryIndexCondition, idx.Condition, err))
 	}
@@ -149,25 +283,75 @@ func readQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) di
 		diags = append(diags, *diagForValueSet(keyQueryIndexFields, idx.IndexKey, err))
 	}
 
+	partitionBy := parsePartitionBy(idx.Partition)
+	if err := d.Set(keyQueryIndexPartitionBy, partitionBy); err != nil {
+		diags = append(diags, *diagForValueSet(keyQueryIndexPartitionBy, partitionBy, err))
+	}
+
+	diags = append(diags, m.(*Connection).setQueryIndexStatus(c, d, idx.ID, queryIndexStatusKeys{
+		NumReplica:   keyQueryIndexNumReplica,
+		NumPartition: keyQueryIndexNumPartition,
+		Status:       keyQueryIndexStatus,
+		Hosts:        keyQueryIndexHosts,
+		Progress:     keyQueryIndexProgress,
+	})...)
+
 	return diags
 }
 
-func deleteQueryIndex(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func updateQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
+	if diags != nil {
+		return diags
+	}
+
+	if d.HasChange(keyQueryIndexNumReplica) {
+		indexName := d.Get(keyQueryIndexName).(string)
+		numReplica := d.Get(keyQueryIndexNumReplica).(int)
+		nodes, err := convertFieldsToList(d.Get(keyQueryIndexNodes).(*schema.Set).List())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		keyspace := getKeyspace(
+			d.Get(keyQueryIndexBucket).(string),
+			d.Get(keyQueryIndexScope).(string),
+			d.Get(keyQueryIndexCollection).(string),
+		)
+
+		cc := m.(*Connection)
+
+		if err := cc.alterQueryIndexReplicas(c, couchbase.QueryIndexManager, d.Id(), indexName, keyspace, numReplica, nodes, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := cc.waitQueryIndexReplicas(c, d.Id(), numReplica, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return readQueryIndex(c, d, m)
+}
+
+func deleteQueryIndex(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
+
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	indexName := d.Get(keyQueryIndexName).(string)
 	bucketName := d.Get(keyQueryIndexBucket).(string)
+	scopeName := d.Get(keyQueryIndexScope).(string)
+	collectionName := d.Get(keyQueryIndexCollection).(string)
 
-	qis := gocb.DropQueryIndexOptions{
-		IgnoreIfNotExists: true,
-	}
+	cc := m.(*Connection)
 
-	if err := couchbase.QueryIndexManager.DropIndex(bucketName, indexName, &qis); err != nil {
+	if err := cc.queryIndexDDL(c, d.Timeout(schema.TimeoutDelete), func() error {
+		return couchbase.QueryIndexManager.dropQueryIndex(c, indexName, bucketName, scopeName, collectionName, d.Timeout(schema.TimeoutDelete))
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -0,0 +1,170 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceQueryIndexBuild() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createQueryIndexBuild,
+		ReadContext:   readQueryIndexBuild,
+		DeleteContext: deleteQueryIndexBuild,
+		Description:   "Build deferred query indexes in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			keyQueryIndexBuildBucket: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Bucket name of built query indexes",
+			},
+			keyQueryIndexBuildIndexes: {
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Required:    true,
+				ForceNew:    true,
+				Description: "IDs of deferred query indexes which are built together",
+			},
+		},
+	}
+}
+
+// convertIndexIDsToList function convert set of query index IDs to list of strings
+func convertIndexIDsToList(rawIDs *schema.Set) ([]string, error) {
+	var ids []string
+
+	for _, rawID := range rawIDs.List() {
+		sub, ok := rawID.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert query index id")
+		}
+		ids = append(ids, sub)
+	}
+	return ids, nil
+}
+
+func createQueryIndexBuild(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
+
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
+	if diags != nil {
+		return diags
+	}
+
+	bucketName := d.Get(keyQueryIndexBuildBucket).(string)
+	indexIDs, err := convertIndexIDsToList(d.Get(keyQueryIndexBuildIndexes).(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// BUILD INDEX statement accepts indexes from one keyspace so deferred indexes are grouped by keyspace
+	deferredNames := map[string][]string{}
+	for _, indexID := range indexIDs {
+		idx, err := couchbase.QueryIndexManager.readQueryIndexByID(c, indexID, d.Timeout(schema.TimeoutCreate))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if idx.bucketName() != bucketName {
+			return diag.Errorf("query index: %s doesn't belong to bucket: %s", idx.Name, bucketName)
+		}
+
+		if idx.State == getDeferredState(true) {
+			deferredNames[idx.keyspace()] = append(deferredNames[idx.keyspace()], idx.Name)
+		}
+	}
+
+	cc := m.(*Connection)
+
+	for keyspace, names := range deferredNames {
+		if err := cc.queryIndexDDL(c, d.Timeout(schema.TimeoutCreate), func() error {
+			return couchbase.QueryIndexManager.buildQueryIndexes(c, keyspace, names, d.Timeout(schema.TimeoutCreate))
+		}); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if err := waitFor(c, logSubsystemIndex, "build query indexes", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
+
+		for _, indexID := range indexIDs {
+			idx, err := couchbase.QueryIndexManager.readQueryIndexByID(c, indexID, d.Timeout(schema.TimeoutCreate))
+			if err != nil {
+				return retry.NonRetryableError(fmt.Errorf("can't build query index id: %s error: %s", indexID, err))
+			}
+
+			if idx.State != getDeferredState(false) {
+				return retry.RetryableError(fmt.Errorf("query index: %s keyspace: %s build in progress: %s", idx.Name, idx.keyspace(), idx.State))
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return diag.FromErr(err)
+	}
+	cc.invalidateIndexStatus()
+
+	d.SetId(id.UniqueId())
+
+	return readQueryIndexBuild(c, d, m)
+}
+
+func readQueryIndexBuild(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemIndex)
+
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
+	if diags != nil {
+		return diags
+	}
+
+	indexIDs, err := convertIndexIDsToList(d.Get(keyQueryIndexBuildIndexes).(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Keep only indexes which are still online so removed or unbuilt indexes are built again
+	var builtIDs []string
+	for _, indexID := range indexIDs {
+		idx, err := couchbase.QueryIndexManager.readQueryIndexByID(c, indexID, d.Timeout(schema.TimeoutRead))
+		if err != nil && errors.Is(err, gocb.ErrIndexNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if idx.State == getDeferredState(false) {
+			builtIDs = append(builtIDs, indexID)
+		}
+	}
+
+	if err := d.Set(keyQueryIndexBuildIndexes, builtIDs); err != nil {
+		diags = append(diags, *diagForValueSet(keyQueryIndexBuildIndexes, builtIDs, err))
+	}
+
+	return diags
+}
+
+func deleteQueryIndexBuild(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Index build can't be reverted, built indexes are removed together with query index resources
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,135 @@
+package couchbase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const testAccQueryIndexBuildBasic = `
+resource "couchbase_bucket_manager" "bucket" {
+    name                     = "testAccQueryIndexBuild_basic_bucket_name"
+    ram_quota_mb             = 100
+    flush_enabled            = false
+    max_expire               = 0
+    conflict_resolution_type = "seqno"
+    compression_mode         = "passive"
+    num_replicas             = 0
+}
+
+resource "couchbase_primary_query_index" "primary_index" {
+    name   = "testAccQueryIndexBuild_basic_primary_index_name"
+    bucket = couchbase_bucket_manager.bucket.name
+}
+
+resource "couchbase_query_index" "query_index" {
+    name   = "testAccQueryIndexBuild_basic_query_index_name"
+    bucket = couchbase_bucket_manager.bucket.name
+    fields = [
+        "` + "`" + "action" + "`" + `"
+    ]
+}
+
+resource "couchbase_query_index_build" "build" {
+    bucket    = couchbase_bucket_manager.bucket.name
+    index_ids = [
+        couchbase_primary_query_index.primary_index.id,
+        couchbase_query_index.query_index.id,
+    ]
+}
+`
+
+const testAccQueryIndexBuildNotDeferred = `
+resource "couchbase_bucket_manager" "bucket" {
+    name                     = "testAccQueryIndexBuild_not_deferred_bucket_name"
+    ram_quota_mb             = 100
+    flush_enabled            = false
+    max_expire               = 0
+    conflict_resolution_type = "seqno"
+    compression_mode         = "passive"
+    num_replicas             = 0
+}
+
+resource "couchbase_query_index" "query_index" {
+    name     = "testAccQueryIndexBuild_not_deferred_query_index_name"
+    bucket   = couchbase_bucket_manager.bucket.name
+    deferred = false
+    fields   = [
+        "` + "`" + "action" + "`" + `"
+    ]
+}
+`
+
+// TestAccQueryIndexBuild function verify
+// - build of deferred primary and query indexes
+// - query index creation without deferred state
+func TestAccQueryIndexBuild(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccQueryIndexBuildBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_query_index_build.build", "bucket", "testAccQueryIndexBuild_basic_bucket_name"),
+					resource.TestCheckResourceAttr("couchbase_query_index_build.build", "index_ids.#", "2"),
+					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "deferred", "true"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "deferred", "true"),
+				),
+			},
+			{
+				Config: testAccQueryIndexBuildNotDeferred,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "name", "testAccQueryIndexBuild_not_deferred_query_index_name"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "deferred", "false"),
+				),
+			},
+		},
+	})
+}
+
+// TestQueryIndexBuild function verify with fake cluster
+// - build of deferred query indexes
+// - drift of built query index removed outside of terraform
+func TestQueryIndexBuild(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceQueryIndexBuild()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"primary", "index"} {
+		if err := fake.createPrimaryQueryIndex(context.Background(), name, getKeyspace("bucket", "", ""), queryIndexWith{DeferBuild: true}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := map[string]interface{}{
+		keyQueryIndexBuildBucket:  "bucket",
+		keyQueryIndexBuildIndexes: []interface{}{"1", "2"},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{"index_ids.#": "2"})
+	for id, idx := range fake.indexes {
+		if idx.State != getDeferredState(false) {
+			t.Fatalf("query index id: %s wasn't built: %s", id, idx.State)
+		}
+	}
+	testCheckNoDiff(t, r, state, config, cc)
+
+	delete(fake.indexes, "2")
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{"index_ids.#": "1"})
+	testCheckDiff(t, r, state, config, cc)
+
+	config[keyQueryIndexBuildBucket] = "other"
+	if _, diags := r.Apply(context.Background(), nil, testResourceDiff(t, r, nil, config, cc), cc); !diags.HasError() {
+		t.Fatalf("query index of other bucket was built")
+	}
+
+	testResourceDestroy(t, r, state, cc)
+}
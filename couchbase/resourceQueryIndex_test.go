@@ -1,9 +1,16 @@
 package couchbase
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const testAccQueryIndexExtended = `
@@ -28,6 +35,60 @@ resource "couchbase_query_index" "query_index" {
 }
 `
 
+const testAccQueryIndexCollection = `
+resource "couchbase_bucket_manager" "bucket" {
+    name         = "testAccQueryIndex_collection_bucket_name"
+    ram_quota_mb = 100
+}
+
+resource "couchbase_bucket_scope" "scope" {
+    name   = "testAccQueryIndex_collection_scope"
+    bucket = couchbase_bucket_manager.bucket.name
+}
+
+resource "couchbase_bucket_collection" "collection" {
+    name   = "testAccQueryIndex_collection_collection"
+    scope  = couchbase_bucket_scope.scope.name
+    bucket = couchbase_bucket_manager.bucket.name
+}
+
+resource "couchbase_primary_query_index" "primary_index" {
+    name       = "testAccQueryIndex_collection_primary_index_name"
+    bucket     = couchbase_bucket_manager.bucket.name
+    scope      = couchbase_bucket_scope.scope.name
+    collection = couchbase_bucket_collection.collection.name
+}
+
+resource "couchbase_query_index" "query_index" {
+    name       = "testAccQueryIndex_collection_query_index_name"
+    bucket     = couchbase_bucket_manager.bucket.name
+    scope      = couchbase_bucket_scope.scope.name
+    collection = couchbase_bucket_collection.collection.name
+    fields     = [
+        "` + "`" + "action" + "`" + `"
+    ]
+}
+`
+
+const testAccQueryIndexPartitioned = `
+resource "couchbase_bucket_manager" "bucket" {
+    name         = "testAccQueryIndex_partitioned_bucket_name"
+    ram_quota_mb = 100
+}
+
+resource "couchbase_query_index" "query_index" {
+    name          = "testAccQueryIndex_partitioned_query_index_name"
+    bucket        = couchbase_bucket_manager.bucket.name
+    fields        = [
+        "` + "`" + "action" + "`" + `"
+    ]
+    partition_by  = [
+        "` + "`" + "type" + "`" + `"
+    ]
+    num_partition = 4
+}
+`
+
 // TestAccQueryIndex function verify
 // - query index extended configuration
 func TestAccQueryIndex(t *testing.T) {
@@ -50,8 +111,429 @@ func TestAccQueryIndex(t *testing.T) {
 					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "fields.0", "`action`"),
 					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "num_replica", "0"),
 					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "condition", "(`type` = \"http://example.com\")"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "hosts.#", "1"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "status", "Created"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccQueryIndexCollection function verify
+// - primary and query index in named scope and collection
+func TestAccQueryIndexCollection(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccQueryIndexCollection,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "bucket", "testAccQueryIndex_collection_bucket_name"),
+					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "scope", "testAccQueryIndex_collection_scope"),
+					resource.TestCheckResourceAttr("couchbase_primary_query_index.primary_index", "collection", "testAccQueryIndex_collection_collection"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "bucket", "testAccQueryIndex_collection_bucket_name"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "scope", "testAccQueryIndex_collection_scope"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "collection", "testAccQueryIndex_collection_collection"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "fields.0", "`action`"),
+				),
+			},
+		},
+	})
+}
+
+// TestAccQueryIndexPartitioned function verify
+// - query index partitioned by hash
+func TestAccQueryIndexPartitioned(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccQueryIndexPartitioned,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "name", "testAccQueryIndex_partitioned_query_index_name"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "partition_by.#", "1"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "partition_by.0", "`type`"),
+					resource.TestCheckResourceAttr("couchbase_query_index.query_index", "num_partition", "4"),
 				),
 			},
 		},
 	})
 }
+
+// TestQueryIndex function verify with fake cluster
+// - query index create in collection, replica update with fixed nodes and delete
+// - replicas are added with replica_count on configured nodes and removed with drop_replica
+// - import of existing query index by name
+// - drift of query index removed outside of terraform
+func TestQueryIndex(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceQueryIndex()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Collections("bucket").CreateScope("scope", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Collections("bucket").CreateCollection("scope", "collection", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyQueryIndexName:       "index",
+		keyQueryIndexBucket:     "bucket",
+		keyQueryIndexScope:      "scope",
+		keyQueryIndexCollection: "collection",
+		keyQueryIndexFields:     []interface{}{"`action`"},
+		keyQueryIndexCondition:  "(`type` = \"event\")",
+		keyQueryIndexNodes:      []interface{}{"node2.example.com:8091"},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyQueryIndexName:       "index",
+		keyQueryIndexScope:      "scope",
+		keyQueryIndexCollection: "collection",
+		keyQueryIndexStatus:     "Created",
+		"fields.0":              "`action`",
+		"hosts.0":               "node2.example.com:8091",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyQueryIndexNodes] = []interface{}{"node3.example.com:8091"}
+	if _, diags := r.Apply(context.Background(), nil, testResourceDiff(t, r, nil, config, cc), cc); !diags.HasError() {
+		t.Fatalf("query index was created on node without index service")
+	}
+
+	config[keyQueryIndexNodes] = []interface{}{"node2.example.com:8091", "node1.example.com:8091"}
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"nodes.#": "2", "hosts.#": "1"})
+
+	id := state.ID
+	config[keyQueryIndexNumReplica] = 1
+	if diff := testResourceDiff(t, r, state, config, cc); diff.RequiresNew() {
+		t.Fatalf("query index replica change with fixed nodes is replaced: %v", diff)
+	}
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"id": id, keyQueryIndexNumReplica: "1", "hosts.#": "2"})
+	testCheckNoDiff(t, r, state, config, cc)
+	if alter := fake.alterStatements[len(fake.alterStatements)-1]; !strings.Contains(alter, `"action":"replica_count"`) || !strings.Contains(alter, `"nodes":[`) {
+		t.Fatalf("query index replicas weren't placed on configured nodes: %s", alter)
+	}
+
+	config[keyQueryIndexNumReplica] = 0
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"id": id, keyQueryIndexNumReplica: "0", "hosts.#": "1"})
+	testCheckNoDiff(t, r, state, config, cc)
+	if alter := fake.alterStatements[len(fake.alterStatements)-1]; alter != `WITH {"action":"drop_replica","replicaId":1}` {
+		t.Fatalf("query index replica wasn't dropped: %s", alter)
+	}
+
+	config[keyQueryIndexNumReplica] = 1
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"id": id, keyQueryIndexNumReplica: "1", "hosts.#": "2"})
+
+	imported := testResourceImport(t, r, "bucket/scope/collection/index", cc)
+	testCheckState(t, imported, map[string]string{"id": state.ID, keyQueryIndexCondition: "(`type` = \"event\")"})
+
+	delete(fake.indexes, state.ID)
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.indexes[state.ID]; ok {
+		t.Fatalf("query index wasn't dropped")
+	}
+}
+
+// TestQueryIndexReplicasToDrop function verify that replicas placed outside of configured nodes are dropped first
+// and then replicas with the highest replica ID
+func TestQueryIndexReplicasToDrop(t *testing.T) {
+	status := []queryIndexStatus{
+		{ReplicaID: 0, Hosts: []string{"node3.example.com:8091"}},
+		{ReplicaID: 1, Hosts: []string{"node1.example.com:8091"}},
+		{ReplicaID: 2, Hosts: []string{"node2.example.com:8091"}},
+	}
+	nodes := []string{"node1.example.com:8091", "node2.example.com:8091"}
+
+	cases := []struct {
+		numReplica int
+		nodes      []string
+		expected   []int
+	}{
+		{2, nodes, nil},
+		{1, nodes, []int{0}},
+		{0, nodes, []int{0, 2}},
+		{1, nil, []int{2}},
+	}
+
+	for _, tc := range cases {
+		if drop := getQueryIndexReplicasToDrop(status, tc.numReplica, tc.nodes); fmt.Sprint(drop) != fmt.Sprint(tc.expected) {
+			t.Fatalf("replicas to drop for %d replicas on nodes %v: %v, expected %v", tc.numReplica, tc.nodes, drop, tc.expected)
+		}
+	}
+}
+
+// TestQueryIndexNodes function verify with fake cluster
+// - query index with more placement nodes than replicas is read back without diff
+// - imported query index records configured nodes without replacement
+// - change of nodes replaces query index only when it isn't placed on new nodes
+func TestQueryIndexNodes(t *testing.T) {
+	cc, fake := newFakeConnection()
+	fake.nodes = append(fake.nodes, "node3.example.com:8091")
+	r := resourceQueryIndex()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyQueryIndexName:   "index",
+		keyQueryIndexBucket: "bucket",
+		keyQueryIndexFields: []interface{}{"`action`"},
+		keyQueryIndexNodes:  []interface{}{"node1.example.com:8091", "node2.example.com:8091"},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyQueryIndexNumReplica: "0", "nodes.#": "2", "hosts.#": "1"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	placement := state.Attributes["hosts.0"]
+	imported := testResourceImport(t, r, "bucket/index", cc)
+	if diff := testResourceDiff(t, r, imported, config, cc); diff.RequiresNew() {
+		t.Fatalf("imported query index is replaced: %v", diff)
+	}
+
+	config[keyQueryIndexNodes] = []interface{}{placement, "node3.example.com:8091"}
+	if diff := testResourceDiff(t, r, state, config, cc); diff.RequiresNew() {
+		t.Fatalf("query index placed on new nodes is replaced: %v", diff)
+	}
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{"nodes.#": "2", "hosts.0": placement})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyQueryIndexNodes] = []interface{}{"node3.example.com:8091"}
+	if diff := testResourceDiff(t, r, state, config, cc); !diff.RequiresNew() {
+		t.Fatalf("query index placed on removed node isn't replaced: %v", diff)
+	}
+}
+
+// TestQueryIndexPartitionBy function verify with fake cluster
+// - partition expressions normalized by indexer don't plan replacement of query index
+// - changed partition expressions force replacement of query index
+func TestQueryIndexPartitionBy(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceQueryIndex()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyQueryIndexName:         "index",
+		keyQueryIndexBucket:       "bucket",
+		keyQueryIndexFields:       []interface{}{"`action`"},
+		keyQueryIndexPartitionBy:  []interface{}{"meta().id", "type"},
+		keyQueryIndexNumPartition: 4,
+	}
+	state := testResourceApply(t, r, nil, config, cc)
+
+	fake.indexes[state.ID].Partition = "HASH((meta().`id`), `type`)"
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{
+		"partition_by.0": "(meta().`id`)",
+		"partition_by.1": "`type`",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyQueryIndexPartitionBy] = []interface{}{"meta().id", "lower(type)"}
+	if diff := testResourceDiff(t, r, state, config, cc); !diff.RequiresNew() {
+		t.Fatalf("changed partition expression doesn't replace query index: %v", diff)
+	}
+
+	cases := map[string]string{
+		"(meta().`id`)":     "meta().id",
+		"((lower(`name`)))": "lower(name)",
+		"(`a` + 1) * (`b`)": "(a+1)*(b)",
+		"`address`.`city`":  "address.city",
+	}
+	for expression, expected := range cases {
+		if normalized := normalizeQueryIndexExpression(expression); normalized != expected {
+			t.Errorf("normalizeQueryIndexExpression(%q) = %q, expected %q", expression, normalized, expected)
+		}
+	}
+}
+
+// TestQueryIndexConcurrentCreate function verify with fake cluster that other query index is created while
+// previous query index is still building
+func TestQueryIndexConcurrentCreate(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceQueryIndex()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyQueryIndexName:     "building",
+		keyQueryIndexBucket:   "bucket",
+		keyQueryIndexFields:   []interface{}{"`action`"},
+		keyQueryIndexDeferred: false,
+	}
+	diff := testResourceDiff(t, r, nil, config, cc)
+
+	fake.building = true
+	done := make(chan error, 1)
+	go func() {
+		_, diags := r.Apply(context.Background(), nil, diff, cc)
+		if diags.HasError() {
+			done <- fmt.Errorf("%v", diags)
+			return
+		}
+		done <- nil
+	}()
+
+	for {
+		if idx, _ := fake.readQueryIndexByName(context.Background(), "building", "bucket", "", "", 0); idx != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	config[keyQueryIndexName] = "deferred"
+	config[keyQueryIndexDeferred] = true
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyQueryIndexName: "deferred", keyQueryIndexStatus: "Created"})
+
+	select {
+	case err := <-done:
+		t.Fatalf("building query index create finished before index was built: %v", err)
+	default:
+	}
+
+	fake.mutex.Lock()
+	fake.building = false
+	for _, idx := range fake.indexes {
+		if idx.State == "building" {
+			idx.State = getDeferredState(false)
+		}
+	}
+	fake.mutex.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatalf("cannot create query index: %s", err)
+	}
+}
+
+// TestServerQueryIndexStatusCache function verify with fake couchbase server
+// - refresh of multiple query indexes downloads indexer status of whole cluster once
+// - query index created after indexer status was cached is read from fresh indexer status
+func TestServerQueryIndexStatusCache(t *testing.T) {
+	s := newFakeServer(t)
+	cc := s.connection(t, s.settings())
+	r := resourceQueryIndex()
+
+	testResourceApply(t, resourceBucket(), nil, map[string]interface{}{keyBucketName: "bucket", keyBucketQuota: 100}, cc)
+
+	states := make([]*terraform.InstanceState, 0, 3)
+	for _, name := range []string{"first", "second", "third"} {
+		states = append(states, testResourceApply(t, r, nil, map[string]interface{}{
+			keyQueryIndexName:     name,
+			keyQueryIndexBucket:   "bucket",
+			keyQueryIndexFields:   []interface{}{"`action`"},
+			keyQueryIndexDeferred: false,
+		}, cc))
+	}
+
+	cc.invalidateIndexStatus()
+	requests := s.requestCount(http.MethodGet, "/indexStatus")
+	for _, state := range states {
+		testCheckState(t, testResourceRefresh(t, r, state, cc), map[string]string{keyQueryIndexStatus: "Ready"})
+	}
+	if count := s.requestCount(http.MethodGet, "/indexStatus") - requests; count != 1 {
+		t.Fatalf("indexer status was downloaded %d times during refresh, expected 1", count)
+	}
+
+	state := testResourceApply(t, r, nil, map[string]interface{}{
+		keyQueryIndexName:     "fourth",
+		keyQueryIndexBucket:   "bucket",
+		keyQueryIndexFields:   []interface{}{"`action`"},
+		keyQueryIndexDeferred: false,
+	}, cc)
+	testCheckState(t, state, map[string]string{keyQueryIndexStatus: "Ready", "hosts.#": "1"})
+}
+
+// TestServerQueryIndexRetry function verify with fake couchbase server
+// - query index create is repeated while indexer builds another index
+// - query index create is repeated while indexer is busy longer than provider retry policy allows
+// - query index is read from system:indexes and indexer status
+// - query index create fails without repeating when cluster doesn't have enough indexer nodes
+func TestServerQueryIndexRetry(t *testing.T) {
+	s := newFakeServer(t)
+	settings := s.settings()
+	settings[providerRetry] = []interface{}{
+		map[string]interface{}{
+			providerRetryMaxAttempts: 2,
+			providerRetryBackoff:     10,
+			providerRetryJitter:      false,
+		},
+	}
+	cc := s.connection(t, settings)
+	r := resourceQueryIndex()
+
+	testResourceApply(t, resourceBucket(), nil, map[string]interface{}{keyBucketName: "bucket", keyBucketQuota: 100}, cc)
+
+	config := map[string]interface{}{
+		keyQueryIndexName:      "index",
+		keyQueryIndexBucket:    "bucket",
+		keyQueryIndexFields:    []interface{}{"`action`", "lower(`name`)"},
+		keyQueryIndexCondition: "(`type` = \"event\")",
+		keyQueryIndexDeferred:  false,
+	}
+
+	s.inject(fakeServerFault{
+		method: http.MethodPost,
+		path:   "/query/service",
+		status: http.StatusInternalServerError,
+		body:   `{"requestID":"fake","errors":[{"code":5000,"msg":"GSI CreateIndex() - cause: Build Already In Progress."}],"status":"errors"}`,
+		count:  1,
+	})
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keyQueryIndexName:      "index",
+		keyQueryIndexStatus:    "Ready",
+		keyQueryIndexCondition: "(`type` = \"event\")",
+		"fields.1":             "lower(`name`)",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keyQueryIndexName] = "busy"
+	s.inject(fakeServerFault{
+		method: http.MethodPost,
+		path:   "/query/service",
+		status: http.StatusInternalServerError,
+		body:   `{"requestID":"fake","errors":[{"code":5000,"msg":"GSI CreateIndex() - cause: Build Already In Progress."}],"status":"errors"}`,
+		count:  3,
+	})
+	requests := s.requestCount(http.MethodPost, "/query/service")
+	busy := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, busy, map[string]string{keyQueryIndexName: "busy", keyQueryIndexStatus: "Ready"})
+	if count := s.requestCount(http.MethodPost, "/query/service") - requests; count < 4 {
+		t.Fatalf("query index create was sent %d times, expected at least 4", count)
+	}
+	testResourceDestroy(t, r, busy, cc)
+
+	config[keyQueryIndexName] = "replica"
+	config[keyQueryIndexNumReplica] = 1
+	requests = s.requestCount(http.MethodPost, "/query/service")
+	if _, diags := r.Apply(context.Background(), nil, testResourceDiff(t, r, nil, config, cc), cc); !diags.HasError() {
+		t.Fatalf("query index with replica was created on single indexer node")
+	}
+	if count := s.requestCount(http.MethodPost, "/query/service") - requests; count != 1 {
+		t.Fatalf("query index create was sent %d times, expected 1", count)
+	}
+
+	testResourceDestroy(t, r, state, cc)
+}
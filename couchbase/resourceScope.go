@@ -7,20 +7,36 @@ import (
 	"strings"
 	"time"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// scopeDeletionGuard contains deletion protection attributes of scope
+var scopeDeletionGuard = deletionGuard{
+	resource:   "scope",
+	protection: keyScopeDeletionProtection,
+	allowEmpty: keyScopeAllowDestroyIfEmpty,
+}
+
 func resourceScope() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: createScope,
 		ReadContext:   readScope,
+		UpdateContext: updateScope,
 		DeleteContext: deleteScope,
 		Description:   "Manage scopes in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: scopeDeletionGuard.importState,
 		},
 		Schema: map[string]*schema.Schema{
 			keyScopeBucketName: {
@@ -35,8 +51,14 @@ func resourceScope() *schema.Resource {
 				ForceNew:    true,
 				Description: "Scope name",
 			},
+			keyScopeDeletionProtection:  deletionProtectionSchema(),
+			keyScopeAllowDestroyIfEmpty: allowDestroyIfEmptySchema(),
 		},
 	}
+
+	r.CustomizeDiff = customizeScopeDiff(r.Schema)
+
+	return r
 }
 
 func scopeSettings(
@@ -49,30 +71,49 @@ func scopeSettings(
 	}
 }
 
+// customizeScopeDiff function validates that couchbase version supports scopes and rejects replacement of protected scope
+func customizeScopeDiff(attributes map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+		if err := m.(*Connection).requireClusterVersion("scope", versionCollections); err != nil {
+			return err
+		}
+
+		return scopeDeletionGuard.customizeDiff(d, m.(*Connection), attributes)
+	}
+}
+
 func createScope(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	ss := scopeSettings(
 		d.Get(keyScopeName).(string),
 		d.Get(keyScopeBucketName).(string),
 	)
 
-	cm := couchbase.Cluster.Bucket(ss.Bucket).CollectionsV2()
+	cm := couchbase.Cluster.Collections(ss.Bucket)
 
-	if err := cm.CreateScope(ss.Name, nil); err != nil {
+	tflog.SubsystemDebug(c, logSubsystemCollection, "creating scope", map[string]interface{}{
+		"bucket": ss.Bucket,
+		"name":   ss.Name,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return cm.CreateScope(ss.Name, &gocb.CreateScopeOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(scopeTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemCollection, "create scope", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
 		target := &ErrScopeNotFound{}
-		_, err := findScope(cm, ss.Name)
+		_, err := findScope(cm, ss.Name, d.Timeout(schema.TimeoutCreate))
 		if errors.As(err, &target) {
 			return retry.RetryableError(target)
 		}
@@ -90,30 +131,49 @@ func createScope(c context.Context, d *schema.ResourceData, m interface{}) diag.
 	return readScope(c, d, m)
 }
 
-func deleteScope(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+// updateScope function updates only deletion protection which is stored in terraform state
+func updateScope(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return readScope(c, d, m)
+}
+
+func deleteScope(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	bucketName, scopeName, found := strings.Cut(d.Id(), "/")
 	if !found {
 		return diag.Errorf("cannot delete scope due to malformed ID: %s", d.Id())
 	}
 
-	cm := couchbase.Cluster.Bucket(bucketName).CollectionsV2()
+	if err := scopeDeletionGuard.checkDelete(c, d, couchbase, bucketName); err != nil {
+		return diag.FromErr(err)
+	}
+
+	cm := couchbase.Cluster.Collections(bucketName)
+
+	tflog.SubsystemDebug(c, logSubsystemCollection, "dropping scope", map[string]interface{}{
+		"bucket": bucketName,
+		"name":   scopeName,
+	})
 
-	if err := cm.DropScope(scopeName, nil); err != nil {
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return cm.DropScope(scopeName, &gocb.DropScopeOptions{Timeout: d.Timeout(schema.TimeoutDelete), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
 	return diags
 }
 
-func readScope(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readScope(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemCollection)
+
 	var diags diag.Diagnostics
 
 	bucketName, scopeName, found := strings.Cut(d.Id(), "/")
@@ -121,17 +181,21 @@ func readScope(_ context.Context, d *schema.ResourceData, m interface{}) diag.Di
 		return diag.Errorf("cannot read scope due to malformed ID: %s", d.Id())
 	}
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	cm := couchbase.Cluster.Bucket(bucketName).CollectionsV2()
+	cm := couchbase.Cluster.Collections(bucketName)
 
-	scope, err := findScope(cm, scopeName)
-	if err != nil {
+	scope, err := findScope(cm, scopeName, d.Timeout(schema.TimeoutRead))
+	target := &ErrScopeNotFound{}
+	if errors.As(err, &target) || errors.Is(err, gocb.ErrBucketNotFound) {
 		d.SetId("")
+		return diags
+	}
+
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -3,6 +3,7 @@ package couchbase
 import (
 	"testing"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -33,3 +34,86 @@ func TestAccScope(t *testing.T) {
 		},
 	})
 }
+
+// TestScope function verify with fake cluster
+// - scope create and delete
+// - import of existing scope
+// - drift of scope removed outside of terraform
+func TestScope(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceScope()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyScopeBucketName: "bucket",
+		keyScopeName:       "scope",
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{"id": "bucket/scope", keyScopeName: "scope", keyScopeBucketName: "bucket"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	imported := testResourceImport(t, r, "bucket/scope", cc)
+	testCheckState(t, imported, map[string]string{keyScopeName: "scope", keyScopeBucketName: "bucket"})
+	testCheckNoDiff(t, r, imported, config, cc)
+
+	delete(fake.collections["bucket"], "scope")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	if err := fake.DropBucket("bucket", nil); err != nil {
+		t.Fatal(err)
+	}
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.collections["bucket"]["scope"]; ok {
+		t.Fatalf("scope wasn't dropped")
+	}
+}
+
+// TestScopeDeletionProtection function verify with fake cluster
+// - destroy and replacement of protected scope are rejected
+// - scope is dropped after deletion protection is disabled
+func TestScopeDeletionProtection(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceScope()
+
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		keyScopeBucketName:         "bucket",
+		keyScopeName:               "scope",
+		keyScopeDeletionProtection: true,
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{keyScopeDeletionProtection: "true"})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	testCheckDestroyError(t, r, state, cc, "scope bucket/scope is protected by deletion_protection")
+	if _, ok := fake.collections["bucket"]["scope"]; !ok {
+		t.Fatalf("protected scope was dropped")
+	}
+
+	config[keyScopeName] = "replaced"
+	testCheckPlanError(t, r, state, config, cc, "scope bucket/scope is protected by deletion_protection and cannot be replaced")
+
+	config[keyScopeName] = "scope"
+	config[keyScopeDeletionProtection] = false
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keyScopeDeletionProtection: "false"})
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.collections["bucket"]["scope"]; ok {
+		t.Fatalf("scope wasn't dropped")
+	}
+}
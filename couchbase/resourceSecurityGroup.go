@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -19,6 +20,12 @@ func resourceSecurityGroup() *schema.Resource {
 		UpdateContext: updateSecurityGroup,
 		DeleteContext: deleteSecurityGroup,
 		Description:   "Manage groups in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -74,12 +81,12 @@ func groupSettings(
 }
 
 func createSecurityGroup(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	gs, err := groupSettings(
 		d.Get(keySecurityGroupName).(string),
@@ -91,13 +98,20 @@ func createSecurityGroup(c context.Context, d *schema.ResourceData, m interface{
 		return diag.FromErr(err)
 	}
 
-	if err = couchbase.UserManager.UpsertGroup(*gs, nil); err != nil {
+	tflog.SubsystemDebug(c, logSubsystemSecurity, "upserting security group", map[string]interface{}{
+		"name":  gs.Name,
+		"roles": len(gs.Roles),
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.UserManager.UpsertGroup(*gs, &gocb.UpsertGroupOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(securityGroupTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemSecurity, "create security group", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
-		_, err := couchbase.UserManager.GetGroup(gs.Name, nil)
+		_, err := couchbase.UserManager.GetGroup(gs.Name, &gocb.GetGroupOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
 		if err != nil && errors.Is(err, gocb.ErrGroupNotFound) {
 			return retry.RetryableError(err)
 		}
@@ -115,17 +129,17 @@ func createSecurityGroup(c context.Context, d *schema.ResourceData, m interface{
 	return readSecurityGroup(c, d, m)
 }
 
-func readSecurityGroup(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readSecurityGroup(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	groupID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	group, err := couchbase.UserManager.GetGroup(groupID, nil)
+	group, err := couchbase.UserManager.GetGroup(groupID, &gocb.GetGroupOptions{Timeout: d.Timeout(schema.TimeoutRead), ParentSpan: requestSpan(c)})
 	if err != nil && errors.Is(err, gocb.ErrGroupNotFound) {
 		d.SetId("")
 		return diags
@@ -157,14 +171,14 @@ func readSecurityGroup(_ context.Context, d *schema.ResourceData, m interface{})
 }
 
 func updateSecurityGroup(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	groupID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	if d.HasChanges(
 		keySecurityGroupName,
@@ -183,7 +197,14 @@ func updateSecurityGroup(c context.Context, d *schema.ResourceData, m interface{
 			return diag.FromErr(err)
 		}
 
-		if err := couchbase.UserManager.UpsertGroup(*gs, nil); err != nil {
+		tflog.SubsystemDebug(c, logSubsystemSecurity, "upserting security group", map[string]interface{}{
+			"name":  gs.Name,
+			"roles": len(gs.Roles),
+		})
+
+		if err := m.(*Connection).retryOperation(c, func() error {
+			return couchbase.UserManager.UpsertGroup(*gs, &gocb.UpsertGroupOptions{Timeout: d.Timeout(schema.TimeoutUpdate), ParentSpan: requestSpan(c)})
+		}); err != nil {
 			return diag.FromErr(err)
 		}
 	}
@@ -191,18 +212,24 @@ func updateSecurityGroup(c context.Context, d *schema.ResourceData, m interface{
 	return readSecurityGroup(c, d, m)
 }
 
-func deleteSecurityGroup(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func deleteSecurityGroup(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	groupID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	if err := couchbase.UserManager.DropGroup(groupID, nil); err != nil {
-		diag.FromErr(err)
+	tflog.SubsystemDebug(c, logSubsystemSecurity, "dropping security group", map[string]interface{}{
+		"name": groupID,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.UserManager.DropGroup(groupID, &gocb.DropGroupOptions{Timeout: d.Timeout(schema.TimeoutDelete), ParentSpan: requestSpan(c)})
+	}); err != nil {
+		return diag.FromErr(err)
 	}
 
 	d.SetId("")
@@ -3,6 +3,7 @@ package couchbase
 import (
 	"testing"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -59,3 +60,55 @@ func TestAccGroup(t *testing.T) {
 		},
 	})
 }
+
+// TestGroup function verify with fake cluster
+// - group create, update and delete
+// - import of existing group
+// - drift of group changed or removed outside of terraform
+func TestGroup(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceSecurityGroup()
+
+	config := map[string]interface{}{
+		keySecurityGroupName:        "group",
+		keySecurityGroupDescription: "description",
+		keySecurityGroupRole: []interface{}{
+			map[string]interface{}{keySecurityGroupRoleName: "query_select", keySecurityGroupRoleBucket: "bucket"},
+		},
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keySecurityGroupName:        "group",
+		keySecurityGroupDescription: "description",
+		"role.#":                    "1",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keySecurityGroupLdapReference] = "OU=group"
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keySecurityGroupLdapReference: "OU=group"})
+	if fake.groups["group"].LDAPGroupReference != "OU=group" {
+		t.Fatalf("group wasn't updated: %v", fake.groups["group"])
+	}
+
+	imported := testResourceImport(t, r, "group", cc)
+	testCheckState(t, imported, map[string]string{keySecurityGroupName: "group", "role.#": "1"})
+	testCheckNoDiff(t, r, imported, config, cc)
+
+	group := fake.groups["group"]
+	group.Roles = append(group.Roles, gocb.Role{Name: "query_insert", Bucket: "bucket"})
+	fake.groups["group"] = group
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{"role.#": "2"})
+	testCheckDiff(t, r, state, config, cc)
+
+	delete(fake.groups, "group")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.groups["group"]; ok {
+		t.Fatalf("group wasn't dropped")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -19,6 +20,12 @@ func resourceSecurityUser() *schema.Resource {
 		UpdateContext: updateSecurityUser,
 		DeleteContext: deleteSecurityUser,
 		Description:   "Manage users in couchbase",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -93,12 +100,12 @@ func userSettings(
 }
 
 func createSecurityUser(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	us, err := userSettings(
 		d.Get(keySecurityUserUsername).(string),
@@ -111,13 +118,21 @@ func createSecurityUser(c context.Context, d *schema.ResourceData, m interface{}
 		return diag.FromErr(err)
 	}
 
-	if err = couchbase.UserManager.UpsertUser(*us, nil); err != nil {
+	tflog.SubsystemDebug(c, logSubsystemSecurity, "upserting security user", map[string]interface{}{
+		"username": us.Username,
+		"roles":    len(us.Roles),
+		"groups":   us.Groups,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.UserManager.UpsertUser(*us, &gocb.UpsertUserOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
+	}); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := retry.RetryContext(c, time.Duration(securityUserTimeoutCreate)*time.Second, func() *retry.RetryError {
+	if err := waitFor(c, logSubsystemSecurity, "create security user", d.Timeout(schema.TimeoutCreate), func() *retry.RetryError {
 
-		_, err := couchbase.UserManager.GetUser(us.Username, nil)
+		_, err := couchbase.UserManager.GetUser(us.Username, &gocb.GetUserOptions{Timeout: d.Timeout(schema.TimeoutCreate), ParentSpan: requestSpan(c)})
 		if err != nil && errors.Is(err, gocb.ErrUserNotFound) {
 			return retry.RetryableError(err)
 		}
@@ -135,17 +150,17 @@ func createSecurityUser(c context.Context, d *schema.ResourceData, m interface{}
 	return readSecurityUser(c, d, m)
 }
 
-func readSecurityUser(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func readSecurityUser(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	userID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	user, err := couchbase.UserManager.GetUser(userID, nil)
+	user, err := couchbase.UserManager.GetUser(userID, &gocb.GetUserOptions{Timeout: d.Timeout(schema.TimeoutRead), ParentSpan: requestSpan(c)})
 	if err != nil && errors.Is(err, gocb.ErrUserNotFound) {
 		d.SetId("")
 		return diags
@@ -179,14 +194,14 @@ func readSecurityUser(_ context.Context, d *schema.ResourceData, m interface{})
 }
 
 func updateSecurityUser(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	userID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
 	if d.HasChanges(
 		keySecurityUserUsername,
@@ -207,7 +222,15 @@ func updateSecurityUser(c context.Context, d *schema.ResourceData, m interface{}
 			return diag.FromErr(err)
 		}
 
-		if err := couchbase.UserManager.UpsertUser(*us, nil); err != nil {
+		tflog.SubsystemDebug(c, logSubsystemSecurity, "upserting security user", map[string]interface{}{
+			"username": us.Username,
+			"roles":    len(us.Roles),
+			"groups":   us.Groups,
+		})
+
+		if err := m.(*Connection).retryOperation(c, func() error {
+			return couchbase.UserManager.UpsertUser(*us, &gocb.UpsertUserOptions{Timeout: d.Timeout(schema.TimeoutUpdate), ParentSpan: requestSpan(c)})
+		}); err != nil {
 			return diag.FromErr(err)
 		}
 	}
@@ -215,18 +238,24 @@ func updateSecurityUser(c context.Context, d *schema.ResourceData, m interface{}
 	return readSecurityUser(c, d, m)
 }
 
-func deleteSecurityUser(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func deleteSecurityUser(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c = m.(*Connection).logContext(c, logSubsystemSecurity)
 
 	userID := d.Id()
 
-	couchbase, diags := m.(*Connection).CouchbaseInitialization()
+	couchbase, diags := m.(*Connection).CouchbaseInitialization(c)
 	if diags != nil {
 		return diags
 	}
-	defer couchbase.ConnectionCLose()
 
-	if err := couchbase.UserManager.DropUser(userID, nil); err != nil {
-		diag.FromErr(err)
+	tflog.SubsystemDebug(c, logSubsystemSecurity, "dropping security user", map[string]interface{}{
+		"username": userID,
+	})
+
+	if err := m.(*Connection).retryOperation(c, func() error {
+		return couchbase.UserManager.DropUser(userID, &gocb.DropUserOptions{Timeout: d.Timeout(schema.TimeoutDelete), ParentSpan: requestSpan(c)})
+	}); err != nil {
+		return diag.FromErr(err)
 	}
 
 	d.SetId("")
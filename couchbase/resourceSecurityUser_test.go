@@ -3,6 +3,7 @@ package couchbase
 import (
 	"testing"
 
+	"github.com/couchbase/gocb/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -58,3 +59,53 @@ func TestAccUser(t *testing.T) {
 		},
 	})
 }
+
+// TestUser function verify with fake cluster
+// - user create, update and delete
+// - import of existing user
+// - drift of user changed or removed outside of terraform
+func TestUser(t *testing.T) {
+	cc, fake := newFakeConnection()
+	r := resourceSecurityUser()
+
+	fake.groups["group"] = gocb.Group{Name: "group"}
+
+	config := map[string]interface{}{
+		keySecurityUserUsername: "user",
+		keySecurityUserPassword: "password",
+	}
+
+	state := testResourceApply(t, r, nil, config, cc)
+	testCheckState(t, state, map[string]string{
+		keySecurityUserUsername: "user",
+		keySecurityUserPassword: "password",
+	})
+	testCheckNoDiff(t, r, state, config, cc)
+
+	config[keySecurityUserDisplayName] = "User"
+	config[keySecurityUserGroup] = []interface{}{"group"}
+	state = testResourceApply(t, r, state, config, cc)
+	testCheckState(t, state, map[string]string{keySecurityUserDisplayName: "User", "groups.0": "group"})
+	if fake.users["user"].DisplayName != "User" || fake.users["user"].Password != "password" {
+		t.Fatalf("user wasn't updated: %v", fake.users["user"])
+	}
+
+	imported := testResourceImport(t, r, "user", cc)
+	testCheckState(t, imported, map[string]string{keySecurityUserUsername: "user", keySecurityUserDisplayName: "User"})
+
+	user := fake.users["user"]
+	user.Groups = nil
+	fake.users["user"] = user
+	state = testResourceRefresh(t, r, state, cc)
+	testCheckState(t, state, map[string]string{"groups.#": "0"})
+	testCheckDiff(t, r, state, config, cc)
+
+	delete(fake.users, "user")
+	testCheckRemoved(t, testResourceRefresh(t, r, state, cc))
+
+	state = testResourceApply(t, r, nil, config, cc)
+	testResourceDestroy(t, r, state, cc)
+	if _, ok := fake.users["user"]; ok {
+		t.Fatalf("user wasn't dropped")
+	}
+}
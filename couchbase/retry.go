@@ -0,0 +1,188 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+)
+
+// transientErrors contains gocb errors which are returned when cluster can't process request temporarily
+var transientErrors = []error{
+	gocb.ErrTemporaryFailure,
+	gocb.ErrServiceNotAvailable,
+	gocb.ErrUnambiguousTimeout,
+	gocb.ErrRateLimitedFailure,
+	ErrManagementUnavailable,
+}
+
+// transientErrorMessages contains parts of couchbase error messages which are returned during cluster maintenance
+var transientErrorMessages = []string{
+	"rebalance running",
+	"rebalance is running",
+	"rebalance in progress",
+	"during rebalance",
+	"temporary failure",
+	"service unavailable",
+}
+
+// RetryPolicy struct contains provider settings for repeating operations which failed with transient error
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	Jitter      bool
+	MaxElapsed  time.Duration
+}
+
+// defaultRetryPolicy function returns retry policy which is used when provider retry block isn't set
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: retryMaxAttempts,
+		Backoff:     time.Duration(retryBackoff) * time.Millisecond,
+		Jitter:      true,
+		MaxElapsed:  time.Duration(retryMaxElapsed) * time.Second,
+	}
+}
+
+// isRetryableError function is central error classifier which decides if failed operation can be repeated
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, transient := range transientErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+
+	var requestErr *ErrManagementRequest
+	if errors.As(err, &requestErr) && requestErr.isRetryable() {
+		return true
+	}
+
+	if isHTTPServiceUnavailableError(err) {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHTTPServiceUnavailableError function checks if gocb management request failed with 503. Gocb returns generic
+// http error without service unavailable error when ns_server rejects request during cluster maintenance
+func isHTTPServiceUnavailableError(err error) bool {
+	var httpErr *gocb.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// delay function returns wait time before next attempt. Backoff is doubled after every attempt and
+// randomized when jitter is enabled so concurrent resources don't repeat requests at the same time
+func (rp RetryPolicy) delay(attempt int) time.Duration {
+	backoff := rp.Backoff << (attempt - 1)
+	if backoff <= 0 || backoff > time.Duration(retryMaxBackoff)*time.Second {
+		backoff = time.Duration(retryMaxBackoff) * time.Second
+	}
+
+	if rp.Jitter && backoff > 1 {
+		// nolint:gosec
+		backoff = backoff/2 + rand.N(backoff/2)
+	}
+
+	return backoff
+}
+
+// do function runs operation and repeats it while it fails with retryable error, number of attempts
+// and elapsed time are limited by policy and context deadline. Attempts are logged to retry log subsystem
+// of context
+func (rp RetryPolicy) do(c context.Context, operation func() error) error {
+	if rp.MaxAttempts <= 0 {
+		rp = defaultRetryPolicy()
+	}
+
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil || !isRetryableError(err) || attempt >= rp.MaxAttempts {
+			if attempt > 1 {
+				tflog.SubsystemDebug(c, logSubsystemRetry, "couchbase operation finished after retries", map[string]interface{}{
+					"attempts": attempt,
+					"elapsed":  time.Since(start).String(),
+					"success":  err == nil,
+				})
+			}
+			return err
+		}
+
+		delay := rp.delay(attempt)
+		if rp.MaxElapsed > 0 && time.Since(start)+delay > rp.MaxElapsed {
+			tflog.SubsystemDebug(c, logSubsystemRetry, "couchbase operation retry time exceeded", map[string]interface{}{
+				"attempts": attempt,
+				"elapsed":  time.Since(start).String(),
+			})
+			return err
+		}
+
+		tflog.SubsystemDebug(c, logSubsystemRetry, "couchbase operation failed with transient error, retrying", map[string]interface{}{
+			"attempt":      attempt,
+			"max_attempts": rp.MaxAttempts,
+			"delay":        delay.String(),
+			"error":        err.Error(),
+		})
+
+		select {
+		case <-c.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryOperation function runs couchbase operation with provider retry policy
+func (cc *Connection) retryOperation(c context.Context, operation func() error) error {
+	return cc.Retry.do(cc.logContext(c, logSubsystemRetry), operation)
+}
+
+// waitFor function repeats check until it succeeds or timeout expires. Number of attempts and duration
+// of wait loop are logged to provider log subsystem
+func waitFor(c context.Context, subsystem string, operation string, timeout time.Duration, check retry.RetryFunc) error {
+	attempt := 0
+	start := time.Now()
+
+	err := retry.RetryContext(c, timeout, func() *retry.RetryError {
+		attempt++
+
+		result := check()
+		if result != nil && result.Retryable && result.Err != nil {
+			tflog.SubsystemTrace(c, subsystem, "waiting for couchbase operation", map[string]interface{}{
+				"operation": operation,
+				"attempt":   attempt,
+				"reason":    result.Err.Error(),
+			})
+		}
+
+		return result
+	})
+
+	tflog.SubsystemDebug(c, subsystem, "wait for couchbase operation finished", map[string]interface{}{
+		"operation": operation,
+		"attempts":  attempt,
+		"duration":  time.Since(start).String(),
+		"success":   err == nil,
+	})
+
+	return err
+}
@@ -0,0 +1,100 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// TestIsRetryableError function verify classification of transient and fatal errors
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{fmt.Errorf("create bucket: %w", gocb.ErrTemporaryFailure), true},
+		{fmt.Errorf("upsert user: %w", gocb.ErrServiceNotAvailable), true},
+		{&ErrManagementRequest{StatusCode: 503}, true},
+		{&gocb.HTTPError{InnerError: errors.New("create bucket"), StatusCode: 503}, true},
+		{&ErrManagementRequest{StatusCode: 400, Body: "Cannot create buckets during rebalance"}, true},
+		{&ErrManagementRequest{StatusCode: 404}, false},
+		{errors.New("Build already in progress"), false},
+		{&gocb.HTTPError{InnerError: errors.New("create bucket"), StatusCode: 500}, false},
+		{fmt.Errorf("create bucket: %w", gocb.ErrBucketExists), false},
+		{fmt.Errorf("create scope: %w", gocb.ErrAmbiguousTimeout), false},
+	}
+
+	for _, tc := range cases {
+		if got := isRetryableError(tc.err); got != tc.retryable {
+			t.Errorf("isRetryableError(%v) = %t, expected %t", tc.err, got, tc.retryable)
+		}
+	}
+}
+
+// TestIsHTTPServiceUnavailableError function verify that only gocb http errors with status 503 are transient
+func TestIsHTTPServiceUnavailableError(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{&gocb.HTTPError{InnerError: errors.New("create bucket"), StatusCode: 503}, true},
+		{fmt.Errorf("upsert user: %w", &gocb.HTTPError{InnerError: errors.New("upsert user"), StatusCode: 503}), true},
+		{&gocb.HTTPError{InnerError: errors.New("create bucket"), StatusCode: 500}, false},
+		{&gocb.HTTPError{InnerError: errors.New("create bucket"), StatusCode: 502}, false},
+		{&gocb.HTTPError{InnerError: errors.New("drop bucket"), StatusCode: 404}, false},
+		{&ErrManagementRequest{StatusCode: 503}, false},
+		{errors.New("service unavailable"), false},
+	}
+
+	for _, tc := range cases {
+		if got := isHTTPServiceUnavailableError(tc.err); got != tc.retryable {
+			t.Errorf("isHTTPServiceUnavailableError(%v) = %t, expected %t", tc.err, got, tc.retryable)
+		}
+		if tc.retryable && !isRetryableError(tc.err) {
+			t.Errorf("isRetryableError(%v) = false, expected true", tc.err)
+		}
+	}
+}
+
+// TestRetryPolicy function verify
+// - transient errors are repeated until operation succeeds
+// - number of attempts is limited
+// - fatal errors are not repeated
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Jitter: true, MaxElapsed: time.Second}
+
+	attempts := 0
+	err := policy.do(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return gocb.ErrTemporaryFailure
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("unexpected result err: %v attempts: %d", err, attempts)
+	}
+
+	attempts = 0
+	err = policy.do(context.Background(), func() error {
+		attempts++
+		return gocb.ErrTemporaryFailure
+	})
+	if !errors.Is(err, gocb.ErrTemporaryFailure) || attempts != 3 {
+		t.Fatalf("unexpected result err: %v attempts: %d", err, attempts)
+	}
+
+	attempts = 0
+	err = policy.do(context.Background(), func() error {
+		attempts++
+		return gocb.ErrBucketExists
+	})
+	if !errors.Is(err, gocb.ErrBucketExists) || attempts != 1 {
+		t.Fatalf("unexpected result err: %v attempts: %d", err, attempts)
+	}
+}
@@ -2,6 +2,7 @@ package couchbase
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/couchbase/gocb/v2"
 )
@@ -24,8 +25,8 @@ func (e *ErrScopeNotFound) Error() string {
 
 // findScope function will find scope based on name in couchbase.
 // custom error message is returnet when scope is not found
-func findScope(cm *gocb.CollectionManagerV2, name string) (*gocb.ScopeSpec, error) {
-	scopes, err := cm.GetAllScopes(nil)
+func findScope(cm collectionManager, name string, timeout time.Duration) (*gocb.ScopeSpec, error) {
+	scopes, err := cm.GetAllScopes(&gocb.GetAllScopesOptions{Timeout: timeout})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,259 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	// Supported trace exporters
+	tracingExporterOTLP   = "otlp"
+	tracingExporterFile   = "file"
+	tracingExporterStdout = "stdout"
+
+	// tracerName is instrumentation scope name of provider spans
+	tracerName = "github.com/lukasbudisky/terraform-provider-couchbase"
+	// tracingServiceName is default service name of exported spans
+	tracingServiceName = "terraform-provider-couchbase"
+
+	// Span attribute keys
+	spanAttributeResourceType = "terraform.resource.type"
+	spanAttributeResourceID   = "terraform.resource.id"
+	spanAttributeOperation    = "terraform.operation"
+)
+
+// resourceFunc is common signature of resource create, read, update and delete functions
+type resourceFunc func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// newTracerProvider function creates tracer provider with exporter from provider tracing block.
+// Spans are exported synchronously because terraform stops provider process right after operation
+// and batched spans would be lost. File exporter returns opened file which has to be closed after
+// tracer provider shutdown
+func newTracerProvider(c context.Context, exporter string, endpoint string, insecure bool, file string, serviceName string) (*sdktrace.TracerProvider, io.Closer, error) {
+	var (
+		spanExporter sdktrace.SpanExporter
+		output       *os.File
+		err          error
+	)
+
+	switch exporter {
+	case tracingExporterOTLP:
+		var options []otlptracehttp.Option
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		spanExporter, err = otlptracehttp.New(c, options...)
+	case tracingExporterFile:
+		if file == "" {
+			return nil, nil, fmt.Errorf("%s exporter requires %s", tracingExporterFile, providerTracingFile)
+		}
+
+		output, err = os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, err
+		}
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(output))
+		if err != nil {
+			_ = output.Close()
+		}
+	case tracingExporterStdout:
+		// Terraform uses provider stdout for plugin protocol so spans are written to stderr
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, nil, fmt.Errorf("unsupported trace exporter: %s", exporter)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+
+	if output == nil {
+		return tracerProvider, nil, nil
+	}
+
+	return tracerProvider, output, nil
+}
+
+// tracer function returns provider tracer or noop tracer when tracing isn't enabled
+func (cc *Connection) tracer() trace.Tracer {
+	if cc == nil || cc.Tracer == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	return cc.Tracer
+}
+
+// traceResource function wraps resource create, read, update or delete function in span named after
+// resource type and resource ID
+func traceResource(resourceType string, operation string, f resourceFunc) resourceFunc {
+	return func(c context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		cc, _ := m.(*Connection)
+
+		c, span := cc.tracer().Start(c, resourceSpanName(resourceType, operation, d.Id()), trace.WithAttributes(
+			attribute.String(spanAttributeResourceType, resourceType),
+			attribute.String(spanAttributeOperation, operation),
+		))
+		defer span.End()
+
+		diags := f(c, d, m)
+
+		// Resource ID is known after create
+		span.SetName(resourceSpanName(resourceType, operation, d.Id()))
+		span.SetAttributes(attribute.String(spanAttributeResourceID, d.Id()))
+
+		for _, diagnostic := range diags {
+			if diagnostic.Severity == diag.Error {
+				span.SetStatus(codes.Error, diagnostic.Summary)
+				break
+			}
+		}
+
+		return diags
+	}
+}
+
+// resourceSpanName function returns span name from resource type, operation and resource ID
+func resourceSpanName(resourceType string, operation string, id string) string {
+	if id == "" {
+		return fmt.Sprintf("%s.%s", resourceType, operation)
+	}
+
+	return fmt.Sprintf("%s.%s %s", resourceType, operation, id)
+}
+
+// requestSpan function returns span from context as gocb parent span so SDK spans are nested under
+// resource span. Nil is returned when context doesn't contain recording span
+func requestSpan(c context.Context) gocb.RequestSpan {
+	span := trace.SpanFromContext(c)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	return &otelRequestSpan{c: c, span: span}
+}
+
+// otelRequestTracer struct implements gocb request tracer with opentelemetry tracer
+type otelRequestTracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// newOtelRequestTracer function creates gocb request tracer from opentelemetry tracer provider
+func newOtelRequestTracer(provider trace.TracerProvider) *otelRequestTracer {
+	return &otelRequestTracer{
+		provider: provider,
+		tracer:   provider.Tracer(tracerName),
+	}
+}
+
+// RequestSpan function creates gocb span as child of parent span context
+func (t *otelRequestTracer) RequestSpan(parentContext gocb.RequestSpanContext, operationName string) gocb.RequestSpan {
+	c, ok := parentContext.(context.Context)
+	if !ok || c == nil {
+		c = context.Background()
+	}
+
+	c, span := t.tracer.Start(c, operationName, trace.WithSpanKind(trace.SpanKindClient))
+
+	return &otelRequestSpan{c: c, span: span}
+}
+
+// Wrapped function returns opentelemetry tracer
+func (t *otelRequestTracer) Wrapped() trace.Tracer {
+	return t.tracer
+}
+
+// Provider function returns opentelemetry tracer provider
+func (t *otelRequestTracer) Provider() trace.TracerProvider {
+	return t.provider
+}
+
+// otelRequestSpan struct implements gocb request span with opentelemetry span
+type otelRequestSpan struct {
+	c    context.Context
+	span trace.Span
+}
+
+// End function ends span
+func (s *otelRequestSpan) End() {
+	s.span.End()
+}
+
+// Context function returns context with span which is used as parent of child spans
+func (s *otelRequestSpan) Context() gocb.RequestSpanContext {
+	return s.c
+}
+
+// AddEvent function adds event to span
+func (s *otelRequestSpan) AddEvent(name string, timestamp time.Time) {
+	s.span.AddEvent(name, trace.WithTimestamp(timestamp))
+}
+
+// SetAttribute function adds gocb attribute to span
+func (s *otelRequestSpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case uint32:
+		s.span.SetAttributes(attribute.Int64(key, int64(v)))
+	case uint64:
+		s.span.SetAttributes(attribute.Int64(key, int64(v))) // nolint:gosec
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// Wrapped function returns opentelemetry span
+func (s *otelRequestSpan) Wrapped() trace.Span {
+	return s.span
+}
+
+// traceResources function wraps create, read, update and delete functions of all resources in spans
+func traceResources(resources map[string]*schema.Resource) map[string]*schema.Resource {
+	for resourceType, r := range resources {
+		if r.CreateContext != nil {
+			r.CreateContext = schema.CreateContextFunc(traceResource(resourceType, "create", resourceFunc(r.CreateContext)))
+		}
+		if r.ReadContext != nil {
+			r.ReadContext = schema.ReadContextFunc(traceResource(resourceType, "read", resourceFunc(r.ReadContext)))
+		}
+		if r.UpdateContext != nil {
+			r.UpdateContext = schema.UpdateContextFunc(traceResource(resourceType, "update", resourceFunc(r.UpdateContext)))
+		}
+		if r.DeleteContext != nil {
+			r.DeleteContext = schema.DeleteContextFunc(traceResource(resourceType, "delete", resourceFunc(r.DeleteContext)))
+		}
+	}
+
+	return resources
+}
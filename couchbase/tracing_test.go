@@ -0,0 +1,155 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testTracer function creates connection with tracer which stores spans in memory
+func testTracer() (*Connection, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	return &Connection{Tracer: provider.Tracer(tracerName), tracerProvider: provider}, exporter
+}
+
+// testSpanAttribute function returns value of span attribute
+func testSpanAttribute(span tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// TestTraceResource function verify
+// - resource operation span is named after resource type and ID
+// - management request and SDK spans are children of resource span
+// - failed operation sets error status of span
+func TestTraceResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"conflictResolutionType":"seqno"}`)
+	}))
+	defer server.Close()
+
+	cc, exporter := testTracer()
+	mc := testManagementClient(t, server)
+	mc.tracer = cc.tracer()
+	requestTracer := newOtelRequestTracer(cc.tracerProvider)
+
+	create := traceResource("couchbase_bucket_manager", "create", func(c context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		if err := mc.get(c, managementServiceCluster, "/pools/default/buckets/bucket", nil); err != nil {
+			return diag.FromErr(err)
+		}
+		requestTracer.RequestSpan(requestSpan(c).Context(), "manager_bucket_create_bucket").End()
+
+		d.SetId("bucket")
+		return nil
+	})
+
+	d := resourceBucket().TestResourceData()
+	if diags := create(context.Background(), d, cc); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	request, sdk, resource := spans[0], spans[1], spans[2]
+	if resource.Name != "couchbase_bucket_manager.create bucket" {
+		t.Fatalf("unexpected resource span name: %s", resource.Name)
+	}
+	if testSpanAttribute(resource, spanAttributeResourceID).AsString() != "bucket" {
+		t.Fatalf("unexpected resource span attributes: %v", resource.Attributes)
+	}
+	if request.Name != "GET /pools/default/buckets/bucket" || testSpanAttribute(request, "http.response.status_code").AsInt64() != http.StatusOK {
+		t.Fatalf("unexpected request span: %s %v", request.Name, request.Attributes)
+	}
+	for _, child := range []tracetest.SpanStub{request, sdk} {
+		if child.Parent.SpanID() != resource.SpanContext.SpanID() {
+			t.Fatalf("span %s isn't child of resource span", child.Name)
+		}
+	}
+
+	exporter.Reset()
+
+	read := traceResource("couchbase_bucket_manager", "read", func(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		return diag.Errorf("bucket not found")
+	})
+	if diags := read(context.Background(), d, cc); !diags.HasError() {
+		t.Fatalf("expected diagnostics")
+	}
+
+	spans = exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "couchbase_bucket_manager.read bucket" || spans[0].Status.Code != codes.Error {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+}
+
+// TestTraceResourceDisabled function verify resource operations work without tracer
+func TestTraceResourceDisabled(t *testing.T) {
+	read := traceResource("couchbase_bucket_scope", "read", func(c context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		if requestSpan(c) != nil {
+			return diag.Errorf("unexpected parent span")
+		}
+		return nil
+	})
+
+	if diags := read(context.Background(), resourceScope().TestResourceData(), &Connection{}); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+}
+
+// TestTraceFileExporterClose function verify
+// - file exporter writes spans to file
+// - connection close shuts down tracer provider and closes trace file
+func TestTraceFileExporterClose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+
+	tracerProvider, tracerOutput, err := newTracerProvider(context.Background(), tracingExporterFile, "", false, file, tracingServiceName)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cc := &Connection{Tracer: tracerProvider.Tracer(tracerName), tracerProvider: tracerProvider, tracerOutput: tracerOutput}
+
+	read := traceResource("couchbase_bucket_scope", "read", func(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		return nil
+	})
+	if diags := read(context.Background(), resourceScope().TestResourceData(), cc); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	cc.Close()
+
+	if cc.tracerOutput != nil {
+		t.Fatalf("trace file is still set after close")
+	}
+	if err := tracerOutput.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("trace file isn't closed: %v", err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(content), "couchbase_bucket_scope.read") {
+		t.Fatalf("span isn't written to trace file: %s", content)
+	}
+}
@@ -1,13 +1,12 @@
 package couchbase
 
 import (
-	"crypto/x509"
+	"encoding/pem"
 	"fmt"
-	"io"
-	"os"
 	"strings"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/couchbase/gocbcore/v10/connstr"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -62,37 +61,112 @@ func validateTLSRootCert() schema.SchemaValidateDiagFunc {
 	return func(i interface{}, _ cty.Path) diag.Diagnostics {
 		var diags diag.Diagnostics
 
-		tlsRootCAs := *x509.NewCertPool()
-
 		filePath, ok := i.(string)
 		if !ok {
 			return diag.Errorf("value error: certificate path")
 		}
 
 		if filePath != "" {
-			file, err := os.OpenFile(filePath, os.O_RDONLY, 0600)
+			data, err := readFile(filePath)
 			if err != nil {
 				return diag.FromErr(err)
 			}
 
-			defer func() {
-				err := file.Close()
-				if err != nil {
-					return
-				}
-			}()
-
-			data, err := io.ReadAll(file)
-			if err != nil {
+			if _, err := certificateManagement(data, false); err != nil {
 				return diag.FromErr(err)
 			}
+		}
+
+		return diags
+	}
+}
+
+// validateTLSRootCertPEM function validate TLS root certificates in PEM format
+func validateTLSRootCertPEM() schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		value, ok := i.(string)
+		if !ok {
+			return diag.Errorf("value error: certificate")
+		}
 
-			ok = tlsRootCAs.AppendCertsFromPEM(data)
-			if !ok {
-				return diag.Errorf("cannot append certificate")
+		if value != "" {
+			if _, err := certificateManagement([]byte(value), false); err != nil {
+				return diag.FromErr(err)
 			}
 		}
 
 		return diags
 	}
 }
+
+// validateTLSClientCert function validate TLS client certificate path or inline certificate
+func validateTLSClientCert() schema.SchemaValidateDiagFunc {
+	return validatePEMBlock("certificate", "CERTIFICATE")
+}
+
+// validateTLSClientKey function validate TLS client private key path or inline private key
+func validateTLSClientKey() schema.SchemaValidateDiagFunc {
+	return validatePEMBlock("private key", "PRIVATE KEY")
+}
+
+// validatePEMBlock function verify that value is path to PEM file or inline PEM data with expected block type
+func validatePEMBlock(name string, blockType string) schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		value, ok := i.(string)
+		if !ok {
+			return diag.Errorf("value error: %s", name)
+		}
+
+		if value == "" {
+			return diags
+		}
+
+		data, err := readPEM(value)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil || !strings.HasSuffix(block.Type, blockType) {
+			return diag.Errorf("cannot decode %s in PEM format", name)
+		}
+
+		return diags
+	}
+}
+
+// validateConnectionString function validate couchbase connection string. Only couchbase and couchbases
+// schemes are supported because management REST API endpoints are derived from seed nodes
+func validateConnectionString() schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		value, ok := i.(string)
+		if !ok {
+			return diag.Errorf("value error: connection string")
+		}
+
+		if value == "" {
+			return diags
+		}
+
+		spec, err := connstr.Parse(value)
+		if err != nil {
+			return diag.Errorf("cannot parse connection string: %s", err)
+		}
+
+		if spec.Scheme != "couchbase" && spec.Scheme != "couchbases" {
+			return diag.Errorf("connection string scheme must be couchbase or couchbases, got: %s", spec.Scheme)
+		}
+
+		if len(spec.Addresses) == 0 {
+			return diag.Errorf("connection string doesn't contain any seed node")
+		}
+
+		return diags
+	}
+}
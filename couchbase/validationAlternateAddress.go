@@ -0,0 +1,41 @@
+package couchbase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// validateAlternateAddressPorts function verify service names of alternate address ports
+func validateAlternateAddressPorts() schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		ports, ok := i.(map[string]interface{})
+		if !ok {
+			return diag.Errorf("value error: alternate address ports")
+		}
+
+		for name := range ports {
+			found := false
+			for _, allowed := range alternateAddressPorts {
+				if name == allowed {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  fmt.Sprintf("Alternate address service doesn't exist %s\n", name),
+					Detail:   fmt.Sprintf("Alternate address service must be:\n%s\n", strings.Join(alternateAddressPorts, "\n")),
+				})
+			}
+		}
+		return diags
+	}
+}
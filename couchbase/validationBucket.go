@@ -1,6 +1,7 @@
 package couchbase
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/couchbase/gocb/v2"
@@ -212,3 +213,105 @@ func validateStorageBackend() schema.SchemaValidateDiagFunc {
 		return diags
 	}
 }
+
+// validateHistoryRetentionBytes function verify bucket history retention size
+// Allowed values:
+// - 0 (history size isn't limited)
+// - 2147483648 bytes (2 GiB) and more
+func validateHistoryRetentionBytes() schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		value, ok := i.(int)
+		if !ok {
+			return diag.Errorf("value error: history retention bytes")
+		}
+
+		if value != 0 && value < minHistoryRetentionBytes {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("History retention bytes is too small %d\n", value),
+				Detail:   fmt.Sprintf("History retention bytes must be 0 or at least %d\n", minHistoryRetentionBytes),
+			})
+		}
+		return diags
+	}
+}
+
+// validateBucketCombination function verify combination of bucket settings which server rejects during apply
+// - memcached bucket doesn't support replicas and durability
+// - ephemeral bucket supports only noEviction and nruEviction policies and durability without persistence
+// - couchbase bucket doesn't support noEviction and nruEviction policies
+// - magma storage backend is supported only by couchbase bucket and requires 1 GiB ram quota before Couchbase 8.0
+// - durability isn't supported with more than 2 replicas
+// Ram quota of magma bucket isn't validated when cluster version is unknown
+func validateBucketCombination(settings gocb.BucketSettings, version clusterVersion) error {
+	var errs []error
+
+	switch settings.BucketType {
+	case gocb.MemcachedBucketType:
+		if settings.NumReplicas > 0 {
+			errs = append(errs, fmt.Errorf("%s bucket doesn't support replicas, %s must be 0", settings.BucketType, keyBucketNumReplicas))
+		}
+		if settings.MinimumDurabilityLevel > gocb.DurabilityLevelNone {
+			errs = append(errs, fmt.Errorf("%s bucket doesn't support durability, %s must be %d", settings.BucketType, keyBucketDurabilityLevel, gocb.DurabilityLevelNone))
+		}
+	case gocb.EphemeralBucketType:
+		switch settings.EvictionPolicy {
+		case gocb.EvictionPolicyTypeNoEviction, gocb.EvictionPolicyTypeNotRecentlyUsed:
+		default:
+			errs = append(errs, fmt.Errorf("%s bucket doesn't support %s %s, use %s or %s",
+				settings.BucketType, keyBucketEvictionPolicyType, settings.EvictionPolicy,
+				gocb.EvictionPolicyTypeNoEviction, gocb.EvictionPolicyTypeNotRecentlyUsed))
+		}
+		if settings.MinimumDurabilityLevel > gocb.DurabilityLevelMajority {
+			errs = append(errs, fmt.Errorf("%s bucket doesn't persist data, %s must be %d or %d",
+				settings.BucketType, keyBucketDurabilityLevel, gocb.DurabilityLevelNone, gocb.DurabilityLevelMajority))
+		}
+	case gocb.CouchbaseBucketType:
+		switch settings.EvictionPolicy {
+		case gocb.EvictionPolicyTypeNoEviction, gocb.EvictionPolicyTypeNotRecentlyUsed:
+			errs = append(errs, fmt.Errorf("%s bucket doesn't support %s %s, use %s or %s",
+				settings.BucketType, keyBucketEvictionPolicyType, settings.EvictionPolicy,
+				gocb.EvictionPolicyTypeValueOnly, gocb.EvictionPolicyTypeFull))
+		}
+	}
+
+	if settings.BucketType != gocb.MemcachedBucketType && settings.MinimumDurabilityLevel > gocb.DurabilityLevelNone && settings.NumReplicas > maxDurableReplicas {
+		errs = append(errs, fmt.Errorf("%s %d isn't supported with %d replicas, %s must be %d or less",
+			keyBucketDurabilityLevel, settings.MinimumDurabilityLevel, settings.NumReplicas, keyBucketNumReplicas, maxDurableReplicas))
+	}
+
+	if settings.StorageBackend == gocb.StorageBackendMagma {
+		if settings.BucketType != gocb.CouchbaseBucketType {
+			errs = append(errs, fmt.Errorf("%s %s requires %s %s", keyBucketStorageBackend, settings.StorageBackend, keyBucketBucketType, gocb.CouchbaseBucketType))
+		}
+		if version.known() && !version.atLeast(versionMagmaSmallQuota) && settings.RAMQuotaMB < minMagmaRAMQuota {
+			errs = append(errs, fmt.Errorf("%s %s requires %s %d or more on Couchbase %s, got %d",
+				keyBucketStorageBackend, settings.StorageBackend, keyBucketQuota, minMagmaRAMQuota, version, settings.RAMQuotaMB))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateIntRange function verify that integer attribute is between minimal and maximal value
+func validateIntRange(key string, minValue, maxValue int) schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+
+		value, ok := i.(int)
+		if !ok {
+			return diag.Errorf("value error: %s", key)
+		}
+
+		if value < minValue || value > maxValue {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("%s is out of range %d\n", key, value),
+				Detail:   fmt.Sprintf("%s must be between %d and %d\n", key, minValue, maxValue),
+			})
+		}
+		return diags
+	}
+}
@@ -0,0 +1,156 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Minimal couchbase server versions of version dependent features
+var (
+	versionAlterIndexReplica = clusterVersion{Major: 6, Minor: 5}
+	versionCollections       = clusterVersion{Major: 7, Minor: 0}
+	versionMagma             = clusterVersion{Major: 7, Minor: 1}
+	versionCollectionHistory = clusterVersion{Major: 7, Minor: 2}
+	versionMagmaSmallQuota   = clusterVersion{Major: 8, Minor: 0}
+)
+
+// clusterPools custom structure for couchbase /pools response
+type clusterPools struct {
+	ImplementationVersion string `json:"implementationVersion"`
+	IsEnterprise          bool   `json:"isEnterprise"`
+}
+
+// clusterVersion custom structure for couchbase server version. Zero version means that version is unknown
+type clusterVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Build      string
+	Enterprise bool
+}
+
+// parseClusterVersion function parses couchbase implementation version e.g. 7.6.2-3721-enterprise
+func parseClusterVersion(raw string) (clusterVersion, error) {
+	var version clusterVersion
+
+	release, build, _ := strings.Cut(raw, "-")
+	version.Build = build
+
+	parts := strings.Split(release, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return version, fmt.Errorf("cannot parse couchbase version: %s", raw)
+	}
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return clusterVersion{}, fmt.Errorf("cannot parse couchbase version: %s", raw)
+		}
+		*numbers[i] = number
+	}
+
+	return version, nil
+}
+
+// known function checks if cluster version was detected
+func (v clusterVersion) known() bool {
+	return v.Major > 0
+}
+
+// atLeast function checks if cluster version is same or newer than required major and minor version
+func (v clusterVersion) atLeast(required clusterVersion) bool {
+	if v.Major != required.Major {
+		return v.Major > required.Major
+	}
+	return v.Minor >= required.Minor
+}
+
+// String function returns cluster version in major.minor.patch format
+func (v clusterVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// fetchClusterVersion function downloads couchbase server version from cluster manager
+func (cc *Connection) fetchClusterVersion(c context.Context) (clusterVersion, error) {
+	var pools clusterPools
+
+	if err := cc.management().get(c, managementServiceCluster, "/pools", &pools); err != nil {
+		return clusterVersion{}, err
+	}
+
+	version, err := parseClusterVersion(pools.ImplementationVersion)
+	if err != nil {
+		return clusterVersion{}, err
+	}
+	version.Enterprise = pools.IsEnterprise
+
+	return version, nil
+}
+
+// detectClusterVersion function fetches cluster version and stores it in connection. Cluster which isn't
+// reachable doesn't fail provider configuration, version dependent attributes are not validated in that case
+func (cc *Connection) detectClusterVersion(c context.Context) {
+	c = cc.logContext(c, logSubsystemConnection)
+
+	version, err := cc.fetchClusterVersion(c)
+	if err != nil {
+		tflog.SubsystemWarn(c, logSubsystemConnection, "cannot detect couchbase version", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tflog.SubsystemInfo(c, logSubsystemConnection, "detected couchbase version", map[string]interface{}{
+		"version":    version.String(),
+		"enterprise": version.Enterprise,
+	})
+
+	cc.versionMutex.Lock()
+	cc.Version = version
+	cc.versionMutex.Unlock()
+}
+
+// clusterVersion function returns cluster version detected during provider configuration.
+// Version is fetched again when it wasn't detected yet
+func (cc *Connection) clusterVersion(c context.Context) (clusterVersion, error) {
+	cc.versionMutex.Lock()
+	defer cc.versionMutex.Unlock()
+
+	if cc.Version.known() {
+		return cc.Version, nil
+	}
+
+	version, err := cc.fetchClusterVersion(c)
+	if err != nil {
+		return clusterVersion{}, err
+	}
+	cc.Version = version
+
+	return version, nil
+}
+
+// detectedClusterVersion function returns cluster version detected during provider configuration without
+// fetching it again. Zero version is returned when it wasn't detected
+func (cc *Connection) detectedClusterVersion() clusterVersion {
+	cc.versionMutex.Lock()
+	defer cc.versionMutex.Unlock()
+
+	return cc.Version
+}
+
+// requireClusterVersion function returns error when feature is used on cluster older than required version.
+// Unknown cluster version isn't validated because server returns error during apply anyway
+func (cc *Connection) requireClusterVersion(feature string, required clusterVersion) error {
+	version := cc.detectedClusterVersion()
+
+	if !version.known() || version.atLeast(required) {
+		return nil
+	}
+
+	return fmt.Errorf("%s requires Couchbase %d.%d+, cluster version is %s", feature, required.Major, required.Minor, version)
+}
@@ -0,0 +1,122 @@
+package couchbase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TestParseClusterVersion function verify parsing of couchbase implementation version
+func TestParseClusterVersion(t *testing.T) {
+	cases := []struct {
+		raw      string
+		expected clusterVersion
+		valid    bool
+	}{
+		{"7.6.2-3721-enterprise", clusterVersion{Major: 7, Minor: 6, Patch: 2, Build: "3721-enterprise"}, true},
+		{"7.0.0-5302-community", clusterVersion{Major: 7, Minor: 0, Build: "5302-community"}, true},
+		{"6.6", clusterVersion{Major: 6, Minor: 6}, true},
+		{"", clusterVersion{}, false},
+		{"7", clusterVersion{}, false},
+		{"7.x.1-1234", clusterVersion{}, false},
+	}
+
+	for _, tc := range cases {
+		version, err := parseClusterVersion(tc.raw)
+		if (err == nil) != tc.valid {
+			t.Fatalf("parseClusterVersion(%q) error: %v, expected valid: %t", tc.raw, err, tc.valid)
+		}
+		if version != tc.expected {
+			t.Fatalf("parseClusterVersion(%q) = %+v, expected %+v", tc.raw, version, tc.expected)
+		}
+	}
+
+	if !(clusterVersion{Major: 7, Minor: 2}).atLeast(versionCollectionHistory) || (clusterVersion{Major: 7, Minor: 1, Patch: 9}).atLeast(versionCollectionHistory) {
+		t.Fatalf("unexpected comparison of cluster versions")
+	}
+}
+
+// TestClusterVersion function verify with fake cluster
+// - cluster version is detected from cluster manager and exposed by data source
+// - version dependent attributes are rejected during plan on older cluster
+// - replaced query index isn't validated because it is created with new replica count
+// - query indexes in scope and collection are rejected before Couchbase 7.0
+// - attributes are not validated when cluster version is unknown
+func TestClusterVersion(t *testing.T) {
+	cc, fake := newFakeConnection()
+	fake.version = "7.0.5-7658-enterprise"
+
+	cc.detectClusterVersion(context.Background())
+	if cc.Version.String() != "7.0.5" || !cc.Version.Enterprise {
+		t.Fatalf("unexpected cluster version: %+v", cc.Version)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceClusterVersion().Schema, map[string]interface{}{})
+	if diags := readClusterVersion(context.Background(), d, cc); diags.HasError() {
+		t.Fatalf("cannot read cluster version: %v", diags)
+	}
+	testCheckState(t, d.State(), map[string]string{
+		keyClusterVersionVersion: "7.0.5",
+		keyClusterVersionMajor:   "7",
+		keyClusterVersionMinor:   "0",
+		keyClusterVersionBuild:   "7658-enterprise",
+	})
+
+	testCheckPlanError(t, resourceBucket(), nil, map[string]interface{}{
+		keyBucketName:           "bucket",
+		keyBucketQuota:          100,
+		keyBucketStorageBackend: "magma",
+	}, cc, "storage_backend magma requires Couchbase 7.1+, cluster version is 7.0.5")
+
+	collection := map[string]interface{}{
+		keyCollectionBucketName: "bucket",
+		keyCollectionScopeName:  "scope",
+		keyCollectionName:       "collection",
+		keyCollectionHistory:    true,
+	}
+	testCheckPlanError(t, resourceCollection(), nil, collection, cc, "history requires Couchbase 7.2+")
+
+	r := resourceQueryIndex()
+	index := map[string]interface{}{
+		keyQueryIndexName:   "index",
+		keyQueryIndexBucket: "bucket",
+		keyQueryIndexFields: []interface{}{"`action`"},
+	}
+	cc.Version = clusterVersion{}
+	if err := fake.CreateBucket(gocb.CreateBucketSettings{BucketSettings: gocb.BucketSettings{Name: "bucket"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	state := testResourceApply(t, r, nil, index, cc)
+
+	fake.version = "6.0.4-3082-enterprise"
+	cc.detectClusterVersion(context.Background())
+	index[keyQueryIndexNumReplica] = 1
+	testCheckPlanError(t, r, state, index, cc, "num_replica update requires Couchbase 6.5+")
+	index[keyQueryIndexName] = "replaced"
+	testCheckDiff(t, r, state, index, cc)
+
+	cc.Version = clusterVersion{Major: 6, Minor: 6, Patch: 5}
+	testCheckPlanError(t, resourceScope(), nil, map[string]interface{}{keyScopeBucketName: "bucket", keyScopeName: "scope"}, cc, "scope requires Couchbase 7.0+")
+	testCheckPlanError(t, r, nil, map[string]interface{}{
+		keyQueryIndexName:       "index",
+		keyQueryIndexBucket:     "bucket",
+		keyQueryIndexScope:      "scope",
+		keyQueryIndexCollection: "collection",
+		keyQueryIndexFields:     []interface{}{"`action`"},
+	}, cc, "scope, collection requires Couchbase 7.0+")
+	testCheckPlanError(t, resourcePrimaryQueryIndex(), nil, map[string]interface{}{
+		keyPrimaryQueryIndexName:       "primary",
+		keyPrimaryQueryIndexBucket:     "bucket",
+		keyPrimaryQueryIndexScope:      "scope",
+		keyPrimaryQueryIndexCollection: "collection",
+	}, cc, "scope, collection requires Couchbase 7.0+")
+	testResourceDiff(t, resourcePrimaryQueryIndex(), nil, map[string]interface{}{
+		keyPrimaryQueryIndexName:   "primary",
+		keyPrimaryQueryIndexBucket: "bucket",
+	}, cc)
+
+	cc.Version = clusterVersion{}
+	testResourceDiff(t, resourceCollection(), nil, collection, cc)
+}